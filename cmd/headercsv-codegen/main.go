@@ -0,0 +1,718 @@
+// Command headercsv-codegen generates reflection-free MarshalCSVRecord and UnmarshalCSVRecord methods
+// for the tagged structs in a Go source file.
+//
+// Usage:
+//
+//	headercsv-codegen [flags] file.go
+//
+// The methods are written into file_headercsv.go, or file_headercsv_test.go for a test file.
+// Encoder and Decoder use them automatically.
+// It is suitable for go generate:
+//
+//	//go:generate headercsv-codegen -type Order order.go
+//
+// The supported field types are the basic types except complex numbers, time.Time,
+// the types in the package that implement encoding.TextMarshaler and encoding.TextUnmarshaler,
+// the types of other packages, which are assumed to implement them, and pointers to them.
+// The structs that have other field types, including the types of database/sql and
+// the types in the package that implement driver.Valuer or sql.Scanner,
+// are skipped, and they are encoded by reflection.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+func run(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("headercsv-codegen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	types := flags.String("type", "", "comma-separated list of the struct names; the default is all structs with csv tags")
+	output := flags.String("o", "", "the output file; the default is file_headercsv.go")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: headercsv-codegen [flags] file.go")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	input := flags.Arg(0)
+	var names []string
+	if *types != "" {
+		names = strings.Split(*types, ",")
+	}
+	src, err := generateFile(input, names, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "headercsv-codegen: %v\n", err)
+		return 1
+	}
+
+	out := *output
+	if out == "" {
+		out = outputName(input)
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		fmt.Fprintf(stderr, "headercsv-codegen: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// outputName returns the name of the generated file for input.
+func outputName(input string) string {
+	if base := strings.TrimSuffix(input, "_test.go"); base != input {
+		return base + "_headercsv_test.go"
+	}
+	return strings.TrimSuffix(input, ".go") + "_headercsv.go"
+}
+
+// generateFile generates the methods for the structs in the file input.
+// If names is empty, all structs that have csv tags are generated.
+// The structs that cannot be generated are reported to warn.
+func generateFile(input string, names []string, warn io.Writer) ([]byte, error) {
+	fset := token.NewFileSet()
+	isTest := strings.HasSuffix(input, "_test.go")
+	pkgs, err := parser.ParseDir(fset, filepath.Dir(input), func(fi os.FileInfo) bool {
+		return isTest || !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(input)
+	if err != nil {
+		return nil, err
+	}
+	var pkg *ast.Package
+	var file *ast.File
+	for _, p := range pkgs {
+		for name, f := range p.Files {
+			if a, err := filepath.Abs(name); err == nil && a == abs {
+				pkg, file = p, f
+			}
+		}
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is not found in the package", input)
+	}
+
+	g := newGenerator(pkg, file)
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[name] = true
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || ts.TypeParams != nil {
+				continue
+			}
+			if len(names) > 0 {
+				if !wanted[ts.Name.Name] {
+					continue
+				}
+				delete(wanted, ts.Name.Name)
+			} else if !hasCSVTag(st) {
+				continue
+			}
+			if err := g.generateStruct(ts.Name.Name, st); err != nil {
+				fmt.Fprintf(warn, "headercsv-codegen: skip %s: %v\n", ts.Name.Name, err)
+			}
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("struct %s is not found in %s", name, input)
+	}
+	return g.source()
+}
+
+func hasCSVTag(st *ast.StructType) bool {
+	for _, f := range st.Fields.List {
+		if _, ok := fieldTag(f); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldTag(f *ast.Field) (string, bool) {
+	if f.Tag == nil {
+		return "", false
+	}
+	tag, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(tag).Lookup("csv")
+}
+
+// kind is the way to encode or decode a field.
+type kind int
+
+const (
+	kindUnsupported kind = iota
+	kindString
+	kindBool
+	kindInt
+	kindUint
+	kindFloat32
+	kindFloat64
+	kindTime
+	kindText
+)
+
+var basicKinds = map[string]kind{
+	"string":  kindString,
+	"bool":    kindBool,
+	"int":     kindInt,
+	"int8":    kindInt,
+	"int16":   kindInt,
+	"int32":   kindInt,
+	"int64":   kindInt,
+	"rune":    kindInt,
+	"uint":    kindUint,
+	"uint8":   kindUint,
+	"uint16":  kindUint,
+	"uint32":  kindUint,
+	"uint64":  kindUint,
+	"uintptr": kindUint,
+	"byte":    kindUint,
+	"float32": kindFloat32,
+	"float64": kindFloat64,
+}
+
+// fieldInfo is a field of a struct.
+type fieldInfo struct {
+	name      string // the Go field name
+	column    string // the column name
+	omitEmpty bool
+	layout    string
+	typ       string // the Go type, without the pointer
+	pointer   bool
+	encode    kind
+	decode    kind
+	nonEmpty  string // the format of the condition that the field is not empty, or "" if it is never empty
+}
+
+// methods are the methods of a type in the package.
+type methods struct {
+	marshalValue   bool // MarshalText has a value receiver
+	marshalPointer bool // MarshalText has a pointer receiver
+	unmarshal      bool // UnmarshalText exists
+	value          bool // Value of driver.Valuer exists
+	scan           bool // Scan of sql.Scanner exists
+}
+
+type generator struct {
+	pkgName     string
+	qualifier   string // the qualifier of the headercsv package
+	types       map[string]ast.Expr
+	methods     map[string]*methods
+	fileImports map[string]string // the package name to the import spec in the input file
+	imports     map[string]bool   // the import specs used by the generated code
+	buf         bytes.Buffer
+}
+
+func newGenerator(pkg *ast.Package, file *ast.File) *generator {
+	g := &generator{
+		pkgName:     file.Name.Name,
+		qualifier:   "headercsv.",
+		types:       map[string]ast.Expr{},
+		methods:     map[string]*methods{},
+		fileImports: map[string]string{},
+		imports:     map[string]bool{},
+	}
+	if g.pkgName == "headercsv" {
+		g.qualifier = ""
+	}
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		if name != path[strings.LastIndex(path, "/")+1:] {
+			g.fileImports[name] = name + " " + strconv.Quote(path)
+		} else {
+			g.fileImports[name] = strconv.Quote(path)
+		}
+	}
+
+	// sort the files for the deterministic output.
+	files := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	for _, name := range files {
+		for _, decl := range pkg.Files[name].Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					ts := spec.(*ast.TypeSpec)
+					g.types[ts.Name.Name] = ts.Type
+				}
+			case *ast.FuncDecl:
+				if decl.Recv == nil || len(decl.Recv.List) != 1 {
+					continue
+				}
+				recv := decl.Recv.List[0].Type
+				pointer := false
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv, pointer = star.X, true
+				}
+				ident, ok := recv.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				m := g.methods[ident.Name]
+				if m == nil {
+					m = &methods{}
+					g.methods[ident.Name] = m
+				}
+				switch decl.Name.Name {
+				case "MarshalText":
+					if pointer {
+						m.marshalPointer = true
+					} else {
+						m.marshalValue = true
+					}
+				case "UnmarshalText":
+					m.unmarshal = true
+				case "Value":
+					m.value = true
+				case "Scan":
+					m.scan = true
+				}
+			}
+		}
+	}
+	return g
+}
+
+// resolve returns the kinds to encode and decode the type expr.
+func (g *generator) resolve(expr ast.Expr, pointer bool) (typ string, encode, decode kind) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		typ = expr.Name
+		if k, ok := basicKinds[typ]; ok {
+			return typ, k, k
+		}
+		m := g.methods[typ]
+		if m == nil {
+			m = &methods{}
+		}
+		underlying := g.underlying(typ)
+		encode, decode = underlying, underlying
+		if m.marshalValue || (pointer && m.marshalPointer) {
+			encode = kindText
+		}
+		if m.unmarshal {
+			decode = kindText
+		}
+		if (m.value && encode != kindText) || (m.scan && decode != kindText) {
+			// driver.Valuer and sql.Scanner are supported only by reflection.
+			return "", kindUnsupported, kindUnsupported
+		}
+		return typ, encode, decode
+	case *ast.SelectorExpr:
+		pkg, ok := expr.X.(*ast.Ident)
+		if !ok {
+			return "", kindUnsupported, kindUnsupported
+		}
+		typ = pkg.Name + "." + expr.Sel.Name
+		spec, ok := g.fileImports[pkg.Name]
+		if !ok {
+			return "", kindUnsupported, kindUnsupported
+		}
+		if strings.HasSuffix(spec, `"database/sql"`) {
+			// the types of database/sql implement sql.Scanner and driver.Valuer
+			// instead of encoding.TextMarshaler; they are supported only by reflection.
+			return "", kindUnsupported, kindUnsupported
+		}
+		if typ == "time.Time" {
+			return typ, kindTime, kindTime
+		}
+		// assume that it implements encoding.TextMarshaler and encoding.TextUnmarshaler.
+		return typ, kindText, kindText
+	}
+	return "", kindUnsupported, kindUnsupported
+}
+
+// nonEmpty returns the format of the condition that a value of the type is not empty,
+// which must be consistent with isEmptyValue of the headercsv package.
+// It returns "" if the value is never empty, e.g. structs,
+// and false if the underlying type is unknown.
+func (g *generator) nonEmpty(typ string) (string, bool) {
+	if typ == "time.Time" {
+		return "", true
+	}
+	for i := 0; i < 100; i++ {
+		switch kindOf(typ) {
+		case kindString:
+			return `%s != ""`, true
+		case kindBool:
+			return "%s", true
+		case kindInt, kindUint, kindFloat32, kindFloat64:
+			return "%s != 0", true
+		}
+		expr, ok := g.types[typ]
+		if !ok {
+			// the types in other packages.
+			return "", false
+		}
+		switch expr := expr.(type) {
+		case *ast.Ident:
+			typ = expr.Name
+		case *ast.StructType:
+			return "", true
+		case *ast.ArrayType, *ast.MapType:
+			return "len(%s) != 0", true
+		case *ast.StarExpr, *ast.InterfaceType, *ast.FuncType, *ast.ChanType:
+			return "%s != nil", true
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// kindOf returns the kind of the basic type typ.
+func kindOf(typ string) kind {
+	if k, ok := basicKinds[typ]; ok {
+		return k
+	}
+	return kindUnsupported
+}
+
+// underlying returns the kind of the underlying type of the named type in the package.
+func (g *generator) underlying(name string) kind {
+	for i := 0; i < 100; i++ {
+		expr, ok := g.types[name]
+		if !ok {
+			return kindUnsupported
+		}
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			return kindUnsupported
+		}
+		if k, ok := basicKinds[ident.Name]; ok {
+			return k
+		}
+		name = ident.Name
+	}
+	return kindUnsupported
+}
+
+func (g *generator) structFields(st *ast.StructType) ([]fieldInfo, error) {
+	var fields []fieldInfo
+	for _, f := range st.Fields.List {
+		tag, _ := fieldTag(f)
+		if tag == "-" {
+			continue
+		}
+		if len(f.Names) == 0 {
+			return nil, errors.New("embedded fields are not supported")
+		}
+		column, opts, _ := strings.Cut(tag, ",")
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				return nil, fmt.Errorf("unexported field %s must be tagged with csv:\"-\"", name.Name)
+			}
+			info := fieldInfo{
+				name:   name.Name,
+				column: column,
+			}
+			if info.column == "" {
+				info.column = name.Name
+			}
+			for _, opt := range strings.Split(opts, ",") {
+				if strings.HasPrefix(opt, "pattern=") {
+					// the pattern extends to the end of the tag.
+					// validation options are checked by the Decoder after UnmarshalCSVRecord.
+					break
+				}
+				if opt == "omitempty" {
+					info.omitEmpty = true
+				}
+				if strings.HasPrefix(opt, "layout=") {
+					info.layout = strings.TrimPrefix(opt, "layout=")
+				}
+			}
+
+			expr := f.Type
+			if star, ok := expr.(*ast.StarExpr); ok {
+				expr, info.pointer = star.X, true
+			}
+			info.typ, info.encode, info.decode = g.resolve(expr, info.pointer)
+			if info.encode == kindUnsupported || info.decode == kindUnsupported {
+				return nil, fmt.Errorf("the type of field %s is not supported", name.Name)
+			}
+			if info.omitEmpty && !info.pointer {
+				nonEmpty, ok := g.nonEmpty(info.typ)
+				if !ok {
+					return nil, fmt.Errorf("omitempty of field %s is not supported", name.Name)
+				}
+				info.nonEmpty = nonEmpty
+			}
+			fields = append(fields, info)
+		}
+	}
+	return fields, nil
+}
+
+func (g *generator) generateStruct(name string, st *ast.StructType) error {
+	fields, err := g.structFields(st)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, f := range fields {
+		if seen[f.column] {
+			return fmt.Errorf("column %q is duplicated", f.column)
+		}
+		seen[f.column] = true
+	}
+
+	w := &g.buf
+	fmt.Fprintf(w, "// MarshalCSVRecord implements %sRecordMarshaler.\n", g.qualifier)
+	fmt.Fprintf(w, "func (v *%s) MarshalCSVRecord(header []string) ([]string, error) {\n", name)
+	fmt.Fprintf(w, "record := make([]string, len(header))\n")
+	if len(fields) > 0 {
+		fmt.Fprintf(w, "for i, name := range header {\n")
+		fmt.Fprintf(w, "switch name {\n")
+		for _, f := range fields {
+			fmt.Fprintf(w, "case %s:\n", strconv.Quote(f.column))
+			g.encodeField(f)
+		}
+		fmt.Fprintf(w, "}\n}\n")
+	}
+	fmt.Fprintf(w, "return record, nil\n}\n\n")
+
+	fmt.Fprintf(w, "// UnmarshalCSVRecord implements %sRecordUnmarshaler.\n", g.qualifier)
+	fmt.Fprintf(w, "func (v *%s) UnmarshalCSVRecord(header, record []string) error {\n", name)
+	if len(fields) > 0 {
+		fmt.Fprintf(w, "for i, name := range header {\n")
+		fmt.Fprintf(w, "if i >= len(record) {\nbreak\n}\n")
+		fmt.Fprintf(w, "switch name {\n")
+		for _, f := range fields {
+			fmt.Fprintf(w, "case %s:\n", strconv.Quote(f.column))
+			g.decodeField(f)
+		}
+		fmt.Fprintf(w, "}\n}\n")
+	}
+	fmt.Fprintf(w, "return nil\n}\n\n")
+	return nil
+}
+
+// encodeField writes the code that encodes the field f into record[i].
+// It must be consistent with Encoder.encodeField.
+func (g *generator) encodeField(f fieldInfo) {
+	w := &g.buf
+	x := "v." + f.name
+	closing := ""
+	switch {
+	case f.pointer && f.omitEmpty:
+		fmt.Fprintf(w, "if %s != nil {\n", x)
+		closing = "}\n"
+	case f.pointer:
+		fmt.Fprintf(w, "if %s == nil {\nrecord[i] = \"null\"\n} else {\n", x)
+		closing = "}\n"
+	case f.omitEmpty && f.nonEmpty != "":
+		fmt.Fprintf(w, "if "+f.nonEmpty+" {\n", x)
+		closing = "}\n"
+	}
+	// the methods are called by x, and the pointer is dereferenced automatically.
+	arg := x
+	if f.pointer {
+		arg = "*" + x
+	}
+
+	switch f.encode {
+	case kindBool, kindInt, kindUint, kindFloat32, kindFloat64:
+		g.imports[`"strconv"`] = true
+	}
+	switch f.encode {
+	case kindString:
+		fmt.Fprintf(w, "record[i] = string(%s)\n", arg)
+	case kindBool:
+		fmt.Fprintf(w, "record[i] = strconv.FormatBool(bool(%s))\n", arg)
+	case kindInt:
+		fmt.Fprintf(w, "record[i] = strconv.FormatInt(int64(%s), 10)\n", arg)
+	case kindUint:
+		fmt.Fprintf(w, "record[i] = strconv.FormatUint(uint64(%s), 10)\n", arg)
+	case kindFloat32:
+		fmt.Fprintf(w, "record[i] = strconv.FormatFloat(float64(%s), 'g', -1, 32)\n", arg)
+	case kindFloat64:
+		fmt.Fprintf(w, "record[i] = strconv.FormatFloat(float64(%s), 'g', -1, 64)\n", arg)
+	case kindTime, kindText:
+		if f.encode == kindTime && f.layout != "" {
+			fmt.Fprintf(w, "record[i] = %s.Format(%s)\n", x, strconv.Quote(f.layout))
+			break
+		}
+		fmt.Fprintf(w, "text, err := %s.MarshalText()\n", x)
+		fmt.Fprintf(w, "if err != nil {\nreturn nil, &%sEncodeError{Field: name, Err: err}\n}\n", g.qualifier)
+		fmt.Fprintf(w, "record[i] = string(text)\n")
+	}
+	w.WriteString(closing)
+}
+
+// decodeField writes the code that decodes record[i] into the field f.
+// It must be consistent with Decoder.decodeField.
+func (g *generator) decodeField(f fieldInfo) {
+	w := &g.buf
+	x := "v." + f.name
+	if f.pointer {
+		if pkg, _, ok := strings.Cut(f.typ, "."); ok {
+			g.imports[g.fileImports[pkg]] = true
+		}
+		fmt.Fprintf(w, "if record[i] == \"\" {\n%s = nil\ncontinue\n}\n", x)
+		fmt.Fprintf(w, "if %s == nil {\n%s = new(%s)\n}\n", x, x, f.typ)
+	}
+	// the methods are called by x, and the pointer is dereferenced automatically.
+	target := x
+	if f.pointer {
+		target = "*" + x
+	}
+	fail := func(err string) {
+		if strings.HasPrefix(err, "errors.") {
+			g.imports[`"errors"`] = true
+		}
+		fmt.Fprintf(w, "return &%sDecodeError{Field: name, Err: %s}\n", g.qualifier, err)
+	}
+
+	switch f.decode {
+	case kindBool, kindInt, kindUint, kindFloat32, kindFloat64:
+		g.imports[`"strconv"`] = true
+	case kindTime:
+		if f.layout != "" {
+			g.imports[`"time"`] = true
+		}
+	}
+	switch f.decode {
+	case kindString:
+		fmt.Fprintf(w, "%s = %s(record[i])\n", target, f.typ)
+	case kindBool:
+		fmt.Fprintf(w, "b, err := strconv.ParseBool(record[i])\nif err != nil {\n")
+		fail("err")
+		fmt.Fprintf(w, "}\n%s = %s(b)\n", target, f.typ)
+	case kindInt:
+		fmt.Fprintf(w, "n, err := strconv.ParseInt(record[i], 0, 64)\nif err != nil {\n")
+		fail("err")
+		fmt.Fprintf(w, "}\n")
+		if f.typ != "int64" {
+			fmt.Fprintf(w, "if int64(%s(n)) != n {\n", f.typ)
+			fail(`errors.New("integer overflow")`)
+			fmt.Fprintf(w, "}\n")
+		}
+		fmt.Fprintf(w, "%s = %s(n)\n", target, f.typ)
+	case kindUint:
+		fmt.Fprintf(w, "n, err := strconv.ParseUint(record[i], 0, 64)\nif err != nil {\n")
+		fail("err")
+		fmt.Fprintf(w, "}\n")
+		if f.typ != "uint64" {
+			fmt.Fprintf(w, "if uint64(%s(n)) != n {\n", f.typ)
+			fail(`errors.New("unsigned integer overflow")`)
+			fmt.Fprintf(w, "}\n")
+		}
+		fmt.Fprintf(w, "%s = %s(n)\n", target, f.typ)
+	case kindFloat32, kindFloat64:
+		bits := 64
+		if f.decode == kindFloat32 {
+			bits = 32
+		}
+		fmt.Fprintf(w, "n, err := strconv.ParseFloat(record[i], %d)\nif err != nil {\n", bits)
+		fail("err")
+		fmt.Fprintf(w, "}\n%s = %s(n)\n", target, f.typ)
+	case kindTime, kindText:
+		if f.decode == kindTime && f.layout != "" {
+			fmt.Fprintf(w, "t, err := time.Parse(%s, record[i])\nif err != nil {\n", strconv.Quote(f.layout))
+			fail("err")
+			fmt.Fprintf(w, "}\n%s = t\n", target)
+			break
+		}
+		fmt.Fprintf(w, "if err := %s.UnmarshalText([]byte(record[i])); err != nil {\n", x)
+		fail("err")
+		fmt.Fprintf(w, "}\n")
+	}
+}
+
+// source returns the formatted source code of the generated file.
+func (g *generator) source() ([]byte, error) {
+	body := g.buf.Bytes()
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by headercsv-codegen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", g.pkgName)
+
+	// the standard packages first, and the others.
+	var std, others []string
+	for spec := range g.imports {
+		path := spec[strings.Index(spec, `"`):]
+		if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+			others = append(others, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	if g.qualifier != "" && len(body) > 0 {
+		others = append(others, `headercsv "github.com/shogo82148/go-header-csv"`)
+	}
+	byPath := func(specs []string) {
+		sort.Slice(specs, func(i, j int) bool {
+			return specs[i][strings.Index(specs[i], `"`):] < specs[j][strings.Index(specs[j], `"`):]
+		})
+	}
+	byPath(std)
+	byPath(others)
+	imports := std
+	if len(std) > 0 && len(others) > 0 {
+		imports = append(imports, "")
+	}
+	imports = append(imports, others...)
+	if len(imports) > 0 {
+		fmt.Fprintf(&buf, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+	buf.Write(body)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the generated code: %w", err)
+	}
+	return src, nil
+}
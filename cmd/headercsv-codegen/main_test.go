@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputName(t *testing.T) {
+	tests := map[string]string{
+		"order.go":        "order_headercsv.go",
+		"dir/order.go":    "dir/order_headercsv.go",
+		"order_test.go":   "order_headercsv_test.go",
+		"dir/my_types.go": "dir/my_types_headercsv.go",
+	}
+	for input, want := range tests {
+		if got := outputName(input); got != want {
+			t.Errorf("outputName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+// TestUpToDate checks that the generated code in the headercsv package is up to date.
+func TestUpToDate(t *testing.T) {
+	got, err := generateFile("../../marshaler_test.go", []string{"codegenRecord", "codegenStatusRecord", "codegenMoneyRecord"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("../../marshaler_headercsv_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("marshaler_headercsv_test.go is out of date; run go generate")
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	src := `package orders
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	dec "github.com/shopspring/decimal"
+)
+
+type Order struct {
+	ID      int64        ` + "`csv:\"id\"`" + `
+	Amount  *dec.Decimal ` + "`csv:\"amount,omitempty\"`" + `
+	Ordered time.Time    ` + "`csv:\"ordered,layout=2006-01-02\"`" + `
+}
+
+type Unsupported struct {
+	Tags []string ` + "`csv:\"tags\"`" + `
+}
+
+type Untagged struct {
+	Name string
+}
+
+type Nullable struct {
+	Name sql.NullString ` + "`csv:\"name\"`" + `
+}
+
+type Cents int64
+
+func (c Cents) Value() (driver.Value, error) {
+	return fmt.Sprintf("%d.%02d", c/100, c%100), nil
+}
+
+type Priced struct {
+	Price Cents ` + "`csv:\"price\"`" + `
+}
+`
+	input := filepath.Join(dir, "orders.go")
+	if err := os.WriteFile(input, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	if code := run([]string{input}, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	for _, name := range []string{"Unsupported", "Nullable", "Priced"} {
+		if !strings.Contains(stderr.String(), "skip "+name) {
+			t.Errorf("want a warning about %s, got %q", name, stderr.String())
+		}
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "orders_headercsv.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"import (\n\t\"strconv\"\n\t\"time\"\n\n\theadercsv \"github.com/shogo82148/go-header-csv\"\n\tdec \"github.com/shopspring/decimal\"\n)",
+		"func (v *Order) MarshalCSVRecord(header []string) ([]string, error) {",
+		"func (v *Order) UnmarshalCSVRecord(header, record []string) error {",
+		"v.Amount = new(dec.Decimal)",
+		`t, err := time.Parse("2006-01-02", record[i])`,
+		"return &headercsv.DecodeError{Field: name, Err: err}",
+	} {
+		if !strings.Contains(string(got), s) {
+			t.Errorf("output doesn't contain %q:\n%s", s, got)
+		}
+	}
+	for _, s := range []string{"Unsupported)", "Untagged)", "Nullable)", "Priced)"} {
+		if strings.Contains(string(got), s) {
+			t.Errorf("output contains %q:\n%s", s, got)
+		}
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	if code := run(nil, &bytes.Buffer{}); code != 2 {
+		t.Errorf("want exit code 2, got %d", code)
+	}
+	if code := run([]string{"-type", "Missing", "main.go"}, &bytes.Buffer{}); code != 1 {
+		t.Errorf("want exit code 1, got %d", code)
+	}
+}
@@ -0,0 +1,246 @@
+// Command headercsv-gen generates a Go struct from a sample CSV file.
+//
+// Usage:
+//
+//	headercsv-gen [flags] file.csv
+//
+// The column types are inferred by headercsv.InferSchema.
+// It is suitable for go generate:
+//
+//	//go:generate headercsv-gen -type Order -o order_gen.go orders.csv
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	headercsv "github.com/shogo82148/go-header-csv"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+type config struct {
+	typeName   string
+	pkg        string
+	output     string
+	comma      string
+	maxRecords int
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	var cfg config
+	flags := flag.NewFlagSet("headercsv-gen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&cfg.typeName, "type", "Record", "the name of the generated struct")
+	flags.StringVar(&cfg.pkg, "package", os.Getenv("GOPACKAGE"), "the package name; the default is $GOPACKAGE or main")
+	flags.StringVar(&cfg.output, "o", "", "the output file; the default is stdout")
+	flags.StringVar(&cfg.comma, "comma", ",", "the field delimiter")
+	flags.IntVar(&cfg.maxRecords, "sample", 1000, "the number of records sampled; negative means all records")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: headercsv-gen [flags] file.csv")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if cfg.pkg == "" {
+		cfg.pkg = "main"
+	}
+
+	if err := generateFile(flags.Arg(0), stdout, &cfg); err != nil {
+		fmt.Fprintf(stderr, "headercsv-gen: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func generateFile(input string, stdout io.Writer, cfg *config) error {
+	comma, size := utf8.DecodeRuneInString(cfg.comma)
+	if size == 0 || size != len(cfg.comma) {
+		return fmt.Errorf("invalid delimiter %q", cfg.comma)
+	}
+
+	f, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	schema, err := headercsv.InferSchema(f, &headercsv.InferOptions{
+		Dialect:    &headercsv.Dialect{Comma: comma},
+		MaxRecords: cfg.maxRecords,
+	})
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(schema, cfg.pkg, cfg.typeName, input)
+	if err != nil {
+		return err
+	}
+	if cfg.output == "" {
+		_, err := stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(cfg.output, src, 0o644)
+}
+
+// generate returns the formatted Go source of the struct.
+func generate(schema *headercsv.Schema, pkg, typeName, source string) ([]byte, error) {
+	if !isExported(typeName) {
+		return nil, fmt.Errorf("invalid type name %q", typeName)
+	}
+
+	var body bytes.Buffer
+	usesTime := false
+	names := map[string]int{}
+	columns := map[string]bool{}
+	fmt.Fprintf(&body, "// %s is a record of %s.\n", typeName, source)
+	fmt.Fprintf(&body, "type %s struct {\n", typeName)
+	for i, c := range schema.Columns {
+		name := fieldName(c.Name, i)
+		if n := names[name]; n > 0 {
+			names[name]++
+			name = name + "_" + strconv.Itoa(n+1)
+		} else {
+			names[name] = 1
+		}
+
+		if strings.ContainsAny(c.Name, ",\"") || c.Name == "-" {
+			// the name cannot be expressed in the csv tag.
+			fmt.Fprintf(&body, "\t// column %q cannot be mapped by the csv tag.\n", c.Name)
+			fmt.Fprintf(&body, "\t%s string `csv:\"-\"`\n", name)
+			continue
+		}
+		if columns[c.Name] {
+			fmt.Fprintf(&body, "\t// column %q is duplicated.\n", c.Name)
+			fmt.Fprintf(&body, "\t%s string `csv:\"-\"`\n", name)
+			continue
+		}
+		columns[c.Name] = true
+
+		typ, opts := goType(c)
+		if typ == "time.Time" || typ == "*time.Time" {
+			usesTime = true
+		}
+		tag := "csv:" + strconv.Quote(c.Name+opts)
+		if strings.Contains(tag, "`") {
+			tag = strconv.Quote(tag)
+		} else {
+			tag = "`" + tag + "`"
+		}
+		fmt.Fprintf(&body, "\t%s %s %s\n", name, typ, tag)
+	}
+	body.WriteString("}\n")
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by headercsv-gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	if usesTime {
+		buf.WriteString("import \"time\"\n\n")
+	}
+	buf.Write(body.Bytes())
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.New("failed to format the generated code: " + err.Error())
+	}
+	return src, nil
+}
+
+// goType returns the Go type and the tag options of the column.
+func goType(c headercsv.ColumnSchema) (string, string) {
+	var typ, opts string
+	switch c.Type {
+	case headercsv.TypeInt:
+		typ = "int64"
+	case headercsv.TypeFloat:
+		typ = "float64"
+	case headercsv.TypeBool:
+		typ = "bool"
+	case headercsv.TypeTime:
+		typ = "time.Time"
+		if c.Layout != time.RFC3339 {
+			// time.Time decodes RFC 3339 by default.
+			opts = ",layout=" + c.Layout
+		}
+	default:
+		typ = "string"
+	}
+	if c.Nullable {
+		if typ != "string" {
+			typ = "*" + typ
+		}
+		opts += ",omitempty"
+	}
+	return typ, opts
+}
+
+// fieldName converts the column name into an exported Go identifier.
+func fieldName(column string, index int) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range column {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	name := sb.String()
+	if name == "" {
+		return "Column" + strconv.Itoa(index+1)
+	}
+	for _, initialism := range commonInitialisms {
+		if strings.ToUpper(name) == initialism {
+			return initialism
+		}
+	}
+	for _, initialism := range commonInitialisms {
+		// e.g. UserId -> UserID
+		suffix := initialism[:1] + strings.ToLower(initialism[1:])
+		if strings.HasSuffix(name, suffix) && len(name) > len(suffix) {
+			name = strings.TrimSuffix(name, suffix) + initialism
+			break
+		}
+	}
+	if !isExported(name) {
+		name = "X" + name
+	}
+	return name
+}
+
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	if !unicode.IsUpper(r) {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+var commonInitialisms = []string{
+	"API", "HTTP", "ID", "IP", "JSON", "SKU", "URI", "URL", "UUID",
+}
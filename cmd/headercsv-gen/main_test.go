@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFieldName(t *testing.T) {
+	tests := []struct {
+		column string
+		want   string
+	}{
+		{"name", "Name"},
+		{"id", "ID"},
+		{"user_id", "UserID"},
+		{"order date", "OrderDate"},
+		{"Unit-Price", "UnitPrice"},
+		{"2nd", "X2nd"},
+		{"名前", "X名前"},
+		{"", "Column3"},
+		{"***", "Column3"},
+	}
+	for _, tt := range tests {
+		if got := fieldName(tt.column, 2); got != tt.want {
+			t.Errorf("fieldName(%q) = %q, want %q", tt.column, got, tt.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "orders.csv")
+	data := "order_id;order date;created;price;paid;memo;id\n" +
+		"1;2024/01/02;2024-01-02T03:04:05Z;1.5;true;;a\n" +
+		"2;2024/01/03;;2;false;note;b\n"
+	if err := os.WriteFile(input, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "orders_gen.go")
+
+	var stderr bytes.Buffer
+	code := run([]string{"-type", "Order", "-package", "orders", "-comma", ";", "-o", output, input}, &bytes.Buffer{}, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated by headercsv-gen; DO NOT EDIT.
+
+package orders
+
+import "time"
+
+// Order is a record of ` + input + `.
+type Order struct {
+	OrderID   int64      ` + "`csv:\"order_id\"`" + `
+	OrderDate time.Time  ` + "`csv:\"order date,layout=2006/01/02\"`" + `
+	Created   *time.Time ` + "`csv:\"created,omitempty\"`" + `
+	Price     float64    ` + "`csv:\"price\"`" + `
+	Paid      bool       ` + "`csv:\"paid\"`" + `
+	Memo      string     ` + "`csv:\"memo,omitempty\"`" + `
+	ID        string     ` + "`csv:\"id\"`" + `
+}
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRun_Stdout(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	if err := os.WriteFile(input, []byte("a,a,\"x,y\"\n1,2,3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	for _, s := range []string{"package main", "A int64 `csv:\"a\"`", "A_2 string `csv:\"-\"`", "XY string `csv:\"-\"`"} {
+		if !strings.Contains(stdout.String(), s) {
+			t.Errorf("output doesn't contain %q:\n%s", s, stdout.String())
+		}
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	if code := run(nil, &bytes.Buffer{}, &bytes.Buffer{}); code != 2 {
+		t.Errorf("want exit code 2, got %d", code)
+	}
+	if code := run([]string{"-type", "lower", "testdata/none.csv"}, &bytes.Buffer{}, &bytes.Buffer{}); code != 1 {
+		t.Errorf("want exit code 1, got %d", code)
+	}
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	headercsv "github.com/shogo82148/go-header-csv"
+	"github.com/shogo82148/go-header-csv/internal/jsonobj"
+)
+
+// format is a file format.
+type format string
+
+const (
+	formatCSV    format = "csv"
+	formatTSV    format = "tsv"
+	formatJSON   format = "json"
+	formatNDJSON format = "ndjson"
+)
+
+func (f format) isCSV() bool {
+	return f == formatCSV || f == formatTSV
+}
+
+func parseFormat(s string) (format, error) {
+	switch s {
+	case "csv", "tsv", "json", "ndjson":
+		return format(s), nil
+	case "jsonl":
+		return formatNDJSON, nil
+	}
+	return "", fmt.Errorf("unknown format %q", s)
+}
+
+// formatOf detects the format by the file extension.
+func formatOf(name string) (format, bool) {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	f, err := parseFormat(ext)
+	return f, err == nil
+}
+
+// renames is the -rename flag.
+type renames map[string]string
+
+func (r renames) String() string {
+	pairs := make([]string, 0, len(r))
+	for k, v := range r {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (r renames) Set(s string) error {
+	from, to, ok := strings.Cut(s, "=")
+	if !ok || from == "" || to == "" {
+		return fmt.Errorf("invalid rename %q: want old=new", s)
+	}
+	r[from] = to
+	return nil
+}
+
+func (r renames) apply(name string) string {
+	if to, ok := r[name]; ok {
+		return to
+	}
+	return name
+}
+
+// runeFlag is a flag of a single character.
+type runeFlag struct {
+	r   rune
+	set bool
+}
+
+func (f *runeFlag) String() string {
+	if f.r == 0 {
+		return ""
+	}
+	return string(f.r)
+}
+
+func (f *runeFlag) Set(s string) error {
+	if s == `\t` {
+		s = "\t"
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) {
+		return fmt.Errorf("want a single character, got %q", s)
+	}
+	f.r, f.set = r, true
+	return nil
+}
+
+type convertOptions struct {
+	from, to  format
+	output    string
+	comma     runeFlag
+	outComma  runeFlag
+	comment   runeFlag
+	trimSpace bool
+	crlf      bool
+	renames   renames
+	infer     bool
+	sample    int
+	input     string
+}
+
+func runConvert(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	opts := &convertOptions{renames: renames{}}
+	var from, to string
+	flags := flag.NewFlagSet("headercsv convert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&from, "from", "", "the input format: csv, tsv, json or ndjson; the default is detected by the extension, or csv")
+	flags.StringVar(&to, "to", "", "the output format: csv, tsv, json or ndjson; the default is detected by the extension of -o")
+	flags.StringVar(&opts.output, "o", "", "the output file; the default is stdout")
+	flags.Var(&opts.comma, "comma", "the field delimiter of the input; the default is ',' for csv and '\\t' for tsv")
+	flags.Var(&opts.outComma, "out-comma", "the field delimiter of the output; the default is ',' for csv and '\\t' for tsv")
+	flags.Var(&opts.comment, "comment", "the comment character of the input")
+	flags.BoolVar(&opts.trimSpace, "trim-space", false, "trim the leading white space of the input fields")
+	flags.BoolVar(&opts.crlf, "crlf", false, "use CRLF as the line terminator of the output")
+	flags.Var(opts.renames, "rename", "rename a column: old=new; it can be repeated")
+	flags.BoolVar(&opts.infer, "infer", false, "infer the column types for JSON output; otherwise all values are strings")
+	flags.IntVar(&opts.sample, "sample", 1000, "the number of records sampled by -infer")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: headercsv convert [flags] [input]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	opts.input = flags.Arg(0)
+
+	var err error
+	if opts.from, err = decideFormat(from, opts.input, formatCSV); err != nil {
+		fmt.Fprintf(stderr, "headercsv: %v\n", err)
+		return 2
+	}
+	if opts.to, err = decideFormat(to, opts.output, ""); err != nil {
+		fmt.Fprintf(stderr, "headercsv: %v\n", err)
+		return 2
+	}
+	if opts.to == "" {
+		fmt.Fprintln(stderr, "headercsv: the output format is unknown; use -to")
+		return 2
+	}
+
+	if err := convertFile(opts, stdin, stdout); err != nil {
+		fmt.Fprintf(stderr, "headercsv: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func decideFormat(flagValue, file string, fallback format) (format, error) {
+	if flagValue != "" {
+		return parseFormat(flagValue)
+	}
+	if f, ok := formatOf(file); ok {
+		return f, nil
+	}
+	return fallback, nil
+}
+
+func convertFile(opts *convertOptions, stdin io.Reader, stdout io.Writer) (err error) {
+	in := stdin
+	if opts.input != "" && opts.input != "-" {
+		f, err := os.Open(opts.input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	out := stdout
+	if opts.output != "" {
+		f, err := os.Create(opts.output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		out = f
+	}
+
+	bw := bufio.NewWriter(out)
+	if err := convert(opts, in, bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// convert converts the input in into the output w.
+func convert(opts *convertOptions, in io.Reader, w io.Writer) error {
+	if opts.from.isCSV() {
+		return convertFromCSV(opts, in, w)
+	}
+	return convertFromJSON(opts, in, w)
+}
+
+func (opts *convertOptions) inputDialect() *headercsv.Dialect {
+	d := &headercsv.Dialect{
+		Comma:            ',',
+		Comment:          opts.comment.r,
+		TrimLeadingSpace: opts.trimSpace,
+	}
+	if opts.from == formatTSV {
+		d.Comma = '\t'
+	}
+	if opts.comma.set {
+		d.Comma = opts.comma.r
+	}
+	return d
+}
+
+func (opts *convertOptions) outputDialect() *headercsv.Dialect {
+	d := &headercsv.Dialect{Comma: ','}
+	if opts.to == formatTSV {
+		d.Comma = '\t'
+	}
+	if opts.outComma.set {
+		d.Comma = opts.outComma.r
+	}
+	if opts.crlf {
+		d.LineTerminator = "\r\n"
+	}
+	return d
+}
+
+func convertFromCSV(opts *convertOptions, in io.Reader, w io.Writer) error {
+	dec, err := headercsv.NewDecoderDialect(in, opts.inputDialect())
+	if err != nil {
+		return err
+	}
+
+	if !opts.to.isCSV() {
+		jsonOpts := &headercsv.JSONLinesOptions{Rename: opts.renames}
+		if opts.infer {
+			jsonOpts.InferRecords = opts.sample
+		}
+		jw := newJSONWriter(w, opts.to == formatJSON)
+		if err := dec.DecodeJSONLines(jw, jsonOpts); err != nil {
+			return err
+		}
+		return jw.close()
+	}
+
+	header, err := dec.Header()
+	if errors.Is(err, io.EOF) {
+		// the input is empty.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	renamed := make([]string, len(header))
+	for i, name := range header {
+		renamed[i] = opts.renames.apply(name)
+	}
+
+	enc, err := headercsv.NewEncoderDialect(w, opts.outputDialect())
+	if err != nil {
+		return err
+	}
+	if err := enc.SetHeader(renamed); err != nil {
+		return err
+	}
+	for {
+		var row []string
+		if err := dec.DecodeRecord(&row); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		if err := enc.EncodeRecord(row); err != nil {
+			return err
+		}
+	}
+	enc.Flush()
+	return enc.Error()
+}
+
+func convertFromJSON(opts *convertOptions, in io.Reader, w io.Writer) error {
+	in = bufio.NewReaderSize(in, 1<<20)
+	if opts.to.isCSV() {
+		enc, err := headercsv.NewEncoderDialect(w, opts.outputDialect())
+		if err != nil {
+			return err
+		}
+		// the header is the union of the keys of all objects.
+		if err := enc.EncodeJSONLines(in, &headercsv.JSONLinesOptions{Rename: opts.renames}); err != nil {
+			return err
+		}
+		enc.Flush()
+		return enc.Error()
+	}
+
+	dec := json.NewDecoder(in)
+	if opts.from == formatJSON {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return finishEmpty(opts, w)
+		}
+		if err != nil {
+			return err
+		}
+		if tok != json.Delim('[') {
+			return errors.New("the JSON input must be an array of objects")
+		}
+	}
+	jw := newJSONWriter(w, opts.to == formatJSON)
+	for opts.from != formatJSON || dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		// keep the order of the keys.
+		obj, err := jsonobj.Parse(raw)
+		if err != nil {
+			return err
+		}
+		for i, k := range obj.Keys {
+			obj.Keys[i] = opts.renames.apply(k)
+		}
+		if err := jw.writeValue(obj); err != nil {
+			return err
+		}
+	}
+	return jw.close()
+}
+
+// finishEmpty writes the output for the empty input.
+func finishEmpty(opts *convertOptions, w io.Writer) error {
+	if opts.to == formatJSON {
+		_, err := io.WriteString(w, "[]\n")
+		return err
+	}
+	return nil
+}
+
+// jsonWriter writes JSON objects as an array or JSON Lines.
+type jsonWriter struct {
+	w     io.Writer
+	array bool
+	count int
+}
+
+func newJSONWriter(w io.Writer, array bool) *jsonWriter {
+	return &jsonWriter{w: w, array: array}
+}
+
+// Write writes a line of JSON Lines written by Decoder.DecodeJSONLines,
+// which writes each line by a single call.
+func (w *jsonWriter) Write(p []byte) (int, error) {
+	if err := w.write(bytes.TrimSuffix(p, []byte("\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *jsonWriter) writeValue(v any) error {
+	b, err := jsonobj.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.write(b)
+}
+
+func (w *jsonWriter) write(b []byte) error {
+	var prefix string
+	switch {
+	case !w.array:
+	case w.count == 0:
+		prefix = "[\n"
+	default:
+		prefix = ",\n"
+	}
+	w.count++
+	if _, err := io.WriteString(w.w, prefix); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(b); err != nil {
+		return err
+	}
+	if !w.array {
+		_, err := io.WriteString(w.w, "\n")
+		return err
+	}
+	return nil
+}
+
+func (w *jsonWriter) close() error {
+	if !w.array {
+		return nil
+	}
+	end := "\n]\n"
+	if w.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(w.w, end)
+	return err
+}
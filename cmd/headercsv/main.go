@@ -0,0 +1,45 @@
+// Command headercsv converts between CSV, TSV, JSON and JSON Lines.
+//
+// Usage:
+//
+//	headercsv convert [flags] [input]
+//
+// The input is read from stdin if it is omitted or "-".
+// The formats are csv, tsv, json (an array of objects) and ndjson (JSON Lines),
+// detected by the file extensions if the -from and -to flags are omitted.
+// The conversion is streaming, so it handles large files.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	switch args[0] {
+	case "convert":
+		return runConvert(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return 0
+	}
+	fmt.Fprintf(stderr, "headercsv: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: headercsv <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  convert    convert between csv, tsv, json and ndjson")
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	const csvInput = "id,name,price,active\n1,apple,1.5,true\n2,\"orange, navel\",,false\n"
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		want  string
+	}{
+		{
+			name:  "csv to tsv",
+			args:  []string{"-from", "csv", "-to", "tsv"},
+			input: csvInput,
+			want:  "id\tname\tprice\tactive\n1\tapple\t1.5\ttrue\n2\torange, navel\t\tfalse\n",
+		},
+		{
+			name:  "csv to json",
+			args:  []string{"-to", "json"},
+			input: csvInput,
+			want: `[
+{"id":"1","name":"apple","price":"1.5","active":"true"},
+{"id":"2","name":"orange, navel","price":"","active":"false"}
+]
+`,
+		},
+		{
+			name:  "csv to ndjson with inference and rename",
+			args:  []string{"-to", "ndjson", "-infer", "-rename", "name=fruit"},
+			input: csvInput,
+			want: `{"id":1,"fruit":"apple","price":1.5,"active":true}
+{"id":2,"fruit":"orange, navel","price":null,"active":false}
+`,
+		},
+		{
+			name:  "semicolon to csv with crlf",
+			args:  []string{"-comma", ";", "-to", "csv", "-crlf"},
+			input: "a;b\n1;2,5\n",
+			want:  "a,b\r\n1,\"2,5\"\r\n",
+		},
+		{
+			name:  "ndjson to csv",
+			args:  []string{"-from", "ndjson", "-to", "csv"},
+			input: "{\"b\":1,\"a\":\"x\"}\n{\"c\":null,\"a\":\"y\",\"d\":{\"e\":true}}\n",
+			want:  "b,a,c,d.e\n1,x,,\n,y,,true\n",
+		},
+		{
+			name:  "ndjson with empty objects to csv",
+			args:  []string{"-from", "ndjson", "-to", "csv"},
+			input: "{\"a\":\"x\",\"b\":1}\n{}\n",
+			want:  "a,b\nx,1\n,\n",
+		},
+		{
+			name:  "json to ndjson",
+			args:  []string{"-from", "json", "-to", "ndjson", "-rename", "a=A"},
+			input: `[{"a":1,"b":"<x>"},{"a":2.50}]`,
+			want:  "{\"A\":1,\"b\":\"<x>\"}\n{\"A\":2.50}\n",
+		},
+		{
+			name:  "ndjson keeps the order of keys",
+			args:  []string{"-from", "ndjson", "-to", "ndjson", "-rename", "b=B"},
+			input: "{\"b\":1, \"a\":{ \"z\": \"<y>\", \"c\": [1, 2.0] }}\n",
+			want:  "{\"B\":1,\"a\":{\"z\":\"<y>\",\"c\":[1,2.0]}}\n",
+		},
+		{
+			name:  "ndjson to json",
+			args:  []string{"-from", "ndjson", "-to", "json"},
+			input: "{\"a\":1}\n{\"a\":2}\n",
+			want:  "[\n{\"a\":1},\n{\"a\":2}\n]\n",
+		},
+		{
+			name:  "empty csv to json",
+			args:  []string{"-to", "json"},
+			input: "",
+			want:  "[]\n",
+		},
+		{
+			name:  "empty json array to csv",
+			args:  []string{"-from", "json", "-to", "csv"},
+			input: "[]",
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"convert"}, tt.args...)
+			if code := run(args, strings.NewReader(tt.input), &stdout, &stderr); code != 0 {
+				t.Fatalf("exit code %d: %s", code, stderr.String())
+			}
+			if stdout.String() != tt.want {
+				t.Errorf("got %q, want %q", stdout.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert_Files(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.tsv")
+	output := filepath.Join(dir, "out.jsonl")
+	if err := os.WriteFile(input, []byte("a\tb\n1\tx\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	if code := run([]string{"convert", "-o", output, input}, nil, &bytes.Buffer{}, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"a\":\"1\",\"b\":\"x\"}\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{nil, 2},
+		{[]string{"unknown"}, 2},
+		{[]string{"convert"}, 2},
+		{[]string{"convert", "-to", "xml"}, 2},
+		{[]string{"convert", "-to", "csv", "-rename", "bad"}, 2},
+		{[]string{"convert", "-to", "csv", "missing.csv"}, 1},
+		{[]string{"convert", "-from", "json", "-to", "ndjson"}, 1},
+	}
+	for _, tt := range tests {
+		code := run(tt.args, strings.NewReader(`{"a":1}`), &bytes.Buffer{}, &bytes.Buffer{})
+		if code != tt.code {
+			t.Errorf("%q: got exit code %d, want %d", tt.args, code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package headercsv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// projection is a selection of columns.
+type projection struct {
+	include    []string        // the columns to include in this order, or nil for all columns
+	includeSet map[string]bool // the set of include
+	exclude    map[string]bool // the columns to exclude
+}
+
+// SelectColumns restricts the columns written by the encoder to names, in the order of names.
+// It must be called before the header is written.
+// If the header is derived from a struct type, all names must be its columns.
+func (enc *Encoder) SelectColumns(names ...string) error {
+	if enc.header != nil {
+		return errors.New("headercsv: the header has been already set")
+	}
+	enc.columns = enc.columns.selectColumns(names)
+	return nil
+}
+
+// ExcludeColumns excludes the columns names from the columns written by the encoder.
+// It must be called before the header is written.
+func (enc *Encoder) ExcludeColumns(names ...string) error {
+	if enc.header != nil {
+		return errors.New("headercsv: the header has been already set")
+	}
+	enc.columns = enc.columns.excludeColumns(names)
+	return nil
+}
+
+// SelectColumns restricts the columns decoded by the decoder to names.
+// The other columns are skipped without decoding.
+// If the decoder decodes into a struct type, all names must be its columns.
+func (dec *Decoder) SelectColumns(names ...string) {
+	dec.columns = dec.columns.selectColumns(names)
+}
+
+// ExcludeColumns skips decoding the columns names.
+func (dec *Decoder) ExcludeColumns(names ...string) {
+	dec.columns = dec.columns.excludeColumns(names)
+}
+
+func (p *projection) selectColumns(names []string) *projection {
+	q := &projection{
+		include:    append([]string{}, names...),
+		includeSet: make(map[string]bool, len(names)),
+	}
+	for _, name := range names {
+		q.includeSet[name] = true
+	}
+	if p != nil {
+		q.exclude = p.exclude
+	}
+	return q
+}
+
+func (p *projection) excludeColumns(names []string) *projection {
+	q := &projection{
+		exclude: map[string]bool{},
+	}
+	if p != nil {
+		q.include = p.include
+		q.includeSet = p.includeSet
+		for name := range p.exclude {
+			q.exclude[name] = true
+		}
+	}
+	for _, name := range names {
+		q.exclude[name] = true
+	}
+	return q
+}
+
+// apply returns the header projected from header.
+// If strict is true, all the selected columns must be in header.
+func (p *projection) apply(header []string, strict bool) ([]string, error) {
+	if p == nil {
+		return header, nil
+	}
+	if p.include == nil {
+		ret := make([]string, 0, len(header))
+		for _, name := range header {
+			if !p.exclude[name] {
+				ret = append(ret, name)
+			}
+		}
+		return ret, nil
+	}
+
+	if strict {
+		if err := p.validate(header); err != nil {
+			return nil, err
+		}
+	}
+	ret := make([]string, 0, len(p.include))
+	for _, name := range p.include {
+		if !p.exclude[name] {
+			ret = append(ret, name)
+		}
+	}
+	return ret, nil
+}
+
+// validate checks that all the selected columns are in header.
+func (p *projection) validate(header []string) error {
+	if p == nil || p.include == nil {
+		return nil
+	}
+	names := make(map[string]bool, len(header))
+	for _, name := range header {
+		names[name] = true
+	}
+	for _, name := range p.include {
+		if !names[name] {
+			return fmt.Errorf("headercsv: unknown column %q", name)
+		}
+	}
+	return nil
+}
+
+// validateType checks that all the selected columns are in the record type t.
+func (p *projection) validateType(t reflect.Type) error {
+	if p == nil {
+		return nil
+	}
+	header := recordType(t).TypeHeaderNames()
+	if header == nil {
+		return nil
+	}
+	if err := p.validate(header); err != nil {
+		return fmt.Errorf("%w in %s", err, t.String())
+	}
+	return nil
+}
+
+// selected reports whether the column name is selected.
+func (p *projection) selected(name string) bool {
+	if p == nil {
+		return true
+	}
+	if p.exclude[name] {
+		return false
+	}
+	if p.include == nil {
+		return true
+	}
+	return p.includeSet[name]
+}
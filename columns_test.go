@@ -0,0 +1,206 @@
+package headercsv
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type columnsRecord struct {
+	ID    int    `csv:"id"`
+	Name  string `csv:"name"`
+	Email string `csv:"email"`
+	Age   int    `csv:"age"`
+}
+
+func TestEncoder_SelectColumns(t *testing.T) {
+	in := []columnsRecord{{1, "alice", "alice@example.com", 20}}
+
+	testcases := []struct {
+		name    string
+		include []string
+		exclude []string
+		out     string
+	}{
+		{
+			name:    "select",
+			include: []string{"name", "id"},
+			out:     "name,id\nalice,1\n",
+		},
+		{
+			name:    "exclude",
+			exclude: []string{"email"},
+			out:     "id,name,age\n1,alice,20\n",
+		},
+		{
+			name:    "select and exclude",
+			include: []string{"email", "name", "id"},
+			exclude: []string{"id"},
+			out:     "email,name\nalice@example.com,alice\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			if tc.include != nil {
+				if err := enc.SelectColumns(tc.include...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.exclude != nil {
+				if err := enc.ExcludeColumns(tc.exclude...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := enc.EncodeAll(in); err != nil {
+				t.Fatal(err)
+			}
+			enc.Flush()
+			if err := enc.Error(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tc.out {
+				t.Errorf("got %q, want %q", buf.String(), tc.out)
+			}
+		})
+	}
+}
+
+func TestEncoder_SelectColumns_error(t *testing.T) {
+	t.Run("unknown column", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if err := enc.SelectColumns("id", "phone"); err != nil {
+			t.Fatal(err)
+		}
+		err := enc.EncodeAll([]columnsRecord{{}})
+		var encodeErr *EncodeError
+		if !errors.As(err, &encodeErr) {
+			t.Fatalf("want EncodeError, got %v", err)
+		}
+	})
+
+	t.Run("unknown column in empty slice", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if err := enc.SelectColumns("phone"); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.EncodeAll([]columnsRecord{}); err == nil {
+			t.Error("want error, but none")
+		}
+	})
+
+	t.Run("maps are not validated", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if err := enc.SelectColumns("b", "c"); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.EncodeAll([]map[string]string{{"a": "1", "b": "2"}}); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		if buf.String() != "b,c\n2,\n" {
+			t.Errorf("got %q, want %q", buf.String(), "b,c\n2,\n")
+		}
+	})
+
+	t.Run("after the header", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if err := enc.SetHeader([]string{"id"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.SelectColumns("id"); err == nil {
+			t.Error("want error, but none")
+		}
+		if err := enc.ExcludeColumns("id"); err == nil {
+			t.Error("want error, but none")
+		}
+	})
+}
+
+func TestEncode_orderTag(t *testing.T) {
+	in := []struct {
+		A string `csv:"a,order=2"`
+		B string `csv:"b"`
+		C string `csv:"c,order=1"`
+		D string `csv:"d,omitempty,order=-1"`
+		E string `csv:"e"`
+	}{{"a", "b", "c", "d", "e"}}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.EncodeAll(in); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	// the fields without the order option follow the ordered fields in the declaration order.
+	if want := "d,c,a,b,e\nd,c,a,b,e\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEncode_orderTagInvalid(t *testing.T) {
+	type record struct {
+		A string `csv:"a,order=first"`
+	}
+
+	enc := NewEncoder(&bytes.Buffer{})
+	err := enc.EncodeAll([]record{{"a"}})
+	if err == nil || !strings.Contains(err.Error(), `invalid order option of field A`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := NewEncoder(&bytes.Buffer{}).SetHeaderFromType(reflect.TypeOf(&record{})); err == nil {
+		t.Error("SetHeaderFromType: want error, but none")
+	}
+
+	var v []record
+	if err := NewDecoder(strings.NewReader("a\nx\n")).DecodeAll(&v); err == nil {
+		t.Error("DecodeAll: want error, but none")
+	}
+}
+
+func TestDecoder_SelectColumns(t *testing.T) {
+	in := "id,name,email,age\n1,alice,alice@example.com,not a number\n"
+
+	t.Run("select", func(t *testing.T) {
+		dec := NewDecoder(bytes.NewBufferString(in))
+		dec.SelectColumns("id", "name")
+		var v []columnsRecord
+		if err := dec.DecodeAll(&v); err != nil {
+			t.Fatal(err)
+		}
+		want := []columnsRecord{{ID: 1, Name: "alice"}}
+		if !reflect.DeepEqual(v, want) {
+			t.Errorf("got %#v, want %#v", v, want)
+		}
+	})
+
+	t.Run("exclude", func(t *testing.T) {
+		dec := NewDecoder(bytes.NewBufferString(in))
+		dec.ExcludeColumns("age")
+		var v []map[string]string
+		if err := dec.DecodeAll(&v); err != nil {
+			t.Fatal(err)
+		}
+		want := []map[string]string{{"id": "1", "name": "alice", "email": "alice@example.com"}}
+		if !reflect.DeepEqual(v, want) {
+			t.Errorf("got %#v, want %#v", v, want)
+		}
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		dec := NewDecoder(bytes.NewBufferString(in))
+		dec.SelectColumns("id", "phone")
+		var v columnsRecord
+		if err := dec.DecodeRecord(&v); err == nil {
+			t.Error("want error, but none")
+		}
+	})
+}
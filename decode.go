@@ -1,6 +1,8 @@
 package headercsv
 
 import (
+	"context"
+	"database/sql"
 	"encoding"
 	"encoding/csv"
 	"encoding/json"
@@ -9,16 +11,21 @@ import (
 	"io"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // A DecodeError is returned for decoding errors.
-// Line and column numbers are 1-indexed.
+// Record, line and column numbers are 1-indexed.
 type DecodeError struct {
-	StartLine int    // Line where the record starts
-	Line      int    // Line where the error occurred
-	Column    int    // Column (1-based byte index) where the error occurred
-	Field     string // Field name where the error occurred
-	Err       error  // The actual error
+	Record    int          // Index of the record where the error occurred, excluding the header
+	StartLine int          // Line where the record starts
+	Line      int          // Line where the error occurred
+	Column    int          // Column (1-based byte index) where the error occurred
+	Field     string       // Field name where the error occurred
+	Value     string       // Raw text of the field
+	Type      reflect.Type // Go type that the field was being decoded into
+	Err       error        // The actual error
 }
 
 func (e *DecodeError) Error() string {
@@ -33,17 +40,222 @@ func (e *DecodeError) Unwrap() error {
 	return e.Err
 }
 
+// Snippet renders line, the source text of the line e.Line, with a caret that points to e.Column.
+//
+//	3 | apple,12,5
+//	  |       ^
+func (e *DecodeError) Snippet(line string) string {
+	line = strings.TrimRight(line, "\r\n")
+	col := e.Column - 1
+	if col < 0 {
+		col = 0
+	}
+	if col > len(line) {
+		col = len(line)
+	}
+
+	// keep tabs so that the caret is aligned with the source.
+	var pad strings.Builder
+	for _, r := range line[:col] {
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+	}
+
+	num := strconv.Itoa(e.Line)
+	indent := strings.Repeat(" ", len(num))
+	return num + " | " + line + "\n" + indent + " | " + pad.String() + "^"
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// It makes the error possible to be returned to API clients.
+func (e *DecodeError) MarshalJSON() ([]byte, error) {
+	var typ, msg string
+	if e.Type != nil {
+		typ = e.Type.String()
+	}
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	return json.Marshal(struct {
+		Record    int    `json:"record"`
+		StartLine int    `json:"start_line"`
+		Line      int    `json:"line"`
+		Column    int    `json:"column"`
+		Field     string `json:"field"`
+		Value     string `json:"value"`
+		Type      string `json:"type,omitempty"`
+		Error     string `json:"error"`
+	}{
+		Record:    e.Record,
+		StartLine: e.StartLine,
+		Line:      e.Line,
+		Column:    e.Column,
+		Field:     e.Field,
+		Value:     e.Value,
+		Type:      typ,
+		Error:     msg,
+	})
+}
+
+// DecodeErrors is a list of decoding errors collected in the collect-all-errors mode.
+// See Decoder.CollectErrors.
+type DecodeErrors []*DecodeError
+
+func (e DecodeErrors) Error() string {
+	var buf strings.Builder
+	for i, err := range e {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+// Unwrap returns the collected errors.
+func (e DecodeErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Is reports whether any error in e matches target.
+// It makes errors.Is work on the Go versions that don't support Unwrap() []error.
+func (e DecodeErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error in e that matches target.
+// It makes errors.As work on the Go versions that don't support Unwrap() []error.
+func (e DecodeErrors) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e DecodeErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// A CanceledError is returned when decoding or encoding is stopped by a context.
+type CanceledError struct {
+	Records int   // Number of records processed before the cancellation
+	Err     error // The error returned by ctx.Err()
+}
+
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("headercsv: canceled after %d records: %v", e.Records, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// An Action tells the decoder how to handle a field that fails to decode.
+type Action int
+
+const (
+	// Abort stops decoding the record and reports the error.
+	// In the collect-all-errors mode, the error is collected and the decoder continues.
+	Abort Action = iota
+
+	// SkipRow drops the record and continues decoding from the next record.
+	// The value being decoded is restored by a shallow copy,
+	// so the values referenced by its pointers, maps and slices,
+	// which are decoded in place, may keep the fields decoded before the error.
+	SkipRow
+
+	// UseZero stores the zero value into the field and continues decoding the record.
+	UseZero
+)
+
 // Decoder reads and decodes CSV values from an input stream.
 type Decoder struct {
 	UnmarshalField func(in []byte, out any) error
 
-	header []string
-	r      *csv.Reader
+	// CollectErrors enables the collect-all-errors mode.
+	// In this mode, the decoder doesn't stop at the first field that fails to decode.
+	// It decodes the rest of the record and the rest of the input,
+	// and reports all errors as DecodeErrors.
+	// The records that the CSV parser rejects, e.g. with csv.ErrFieldCount, are collected too;
+	// their DecodeError has no Field.
+	// DecodeAll drops the records that have errors and keeps the others.
+	CollectErrors bool
+
+	// MaxErrors is the maximum number of errors collected in the collect-all-errors mode.
+	// DecodeAll stops decoding when it is reached.
+	// Zero means no limit.
+	MaxErrors int
+
+	// SkipLines is the number of leading records skipped before the header,
+	// or before the first record if the header is set by SetHeader.
+	SkipLines int
+
+	// SkipLine is called with the leading records after SkipLines.
+	// The records are skipped while it returns true.
+	SkipLine func(record []string) bool
+
+	// DetectHeader enables the header detection.
+	// The decoder skips the leading records until it finds a record that contains
+	// at least half of the expected column names; the column names selected by SelectColumns,
+	// or the column names of the target struct type.
+	// The names are compared exactly, without trimming spaces.
+	// It is applied after SkipLines and SkipLine.
+	DetectHeader bool
+
+	// TrailerLines is the number of trailing records withheld from decoding as the trailer.
+	TrailerLines int
+
+	// IsTrailer is called with each record.
+	// If it returns true, the record and the rest of the input are withheld from decoding as the trailer.
+	IsTrailer func(record []string) bool
+
+	// TrailerCount returns the number of records declared in the trailer.
+	// If it is set, the decoder verifies the count when it reaches the end of the input,
+	// and returns an error wrapping ErrTrailerCount instead of io.EOF if it doesn't match.
+	TrailerCount func(trailer [][]string) (int, error)
+
+	// ErrorHandler is called when a field fails to decode, or when the CSV parser rejects a record,
+	// e.g. because of a wrong number of fields or a bare quote.
+	// raw is the raw record that contains the field; it must not be modified.
+	// For the records rejected by the parser, Field of the error is empty,
+	// and raw is the partial record returned with csv.ErrFieldCount, or nil for the other errors;
+	// UseZero decodes the partial record, or skips the record if raw is nil.
+	// The returned Action decides how the decoder handles the error.
+	// If ErrorHandler is nil, the decoder aborts.
+	ErrorHandler func(err *DecodeError, raw []string) Action
+
+	header   []string
+	preamble [][]string
+	columns  *projection
+	r        recordReader
+	records  int  // the number of records read, excluding the header
+	fields   int  // the number of fields in the record most recently read
+	excel    bool // Excel-compatibility mode
+	skipped  bool // the preamble before the records is skipped
 }
 
 // NewDecoder returns a new decoder that reads from r.
+// The input is converted into UTF-8 by NewUTF8Reader.
 func NewDecoder(r io.Reader) *Decoder {
-	return &Decoder{r: csv.NewReader(r)}
+	return &Decoder{r: newCSVReader(r)}
 }
 
 // NewDecoderCSV returns a new decoder that reads from r.
@@ -59,7 +271,7 @@ func (dec *Decoder) Decode(v any) error {
 		dec.UnmarshalField = json.Unmarshal
 	}
 
-	if err := dec.initHeader(); err != nil {
+	if err := dec.initHeader(nil); err != nil {
 		return err
 	}
 
@@ -94,16 +306,30 @@ func (dec *Decoder) Decode(v any) error {
 
 // DecodeRecord reads the next CSV record from its input and stores it in the value pointed to by v.
 func (dec *Decoder) DecodeRecord(v any) error {
+	return dec.DecodeRecordContext(context.Background(), v)
+}
+
+// DecodeRecordContext is like DecodeRecord, but it returns a *CanceledError
+// without reading any record if ctx is done.
+func (dec *Decoder) DecodeRecordContext(ctx context.Context, v any) error {
+	if err := dec.checkContext(ctx); err != nil {
+		return err
+	}
+
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Pointer {
 		return errors.New("headercsv: v is not a pointer")
 	}
 
+	if err := dec.columns.validateType(rv.Type().Elem()); err != nil {
+		return err
+	}
+
 	if dec.UnmarshalField == nil {
 		dec.UnmarshalField = json.Unmarshal
 	}
 
-	if err := dec.initHeader(); err != nil {
+	if err := dec.initHeader(rv.Type().Elem()); err != nil {
 		return err
 	}
 
@@ -113,16 +339,37 @@ func (dec *Decoder) DecodeRecord(v any) error {
 // DecodeAll reads all CSV record from its input.
 // v must be a pinter to a slice or a pointer to an array.
 func (dec *Decoder) DecodeAll(v any) error {
+	return dec.DecodeAllContext(context.Background(), v)
+}
+
+// DecodeAllContext is like DecodeAll, but it stops decoding when ctx is done.
+// The records decoded before the cancellation are stored in v,
+// and the returned error is a *CanceledError that wraps ctx.Err().
+func (dec *Decoder) DecodeAllContext(ctx context.Context, v any) error {
+	if err := dec.checkContext(ctx); err != nil {
+		return err
+	}
+
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Pointer {
 		return errors.New("headercsv: v is not a pointer")
 	}
 
+	if kind := rv.Elem().Kind(); kind == reflect.Slice || kind == reflect.Array {
+		if err := dec.columns.validateType(rv.Type().Elem().Elem()); err != nil {
+			return err
+		}
+	}
+
 	if dec.UnmarshalField == nil {
 		dec.UnmarshalField = json.Unmarshal
 	}
 
-	if err := dec.initHeader(); err != nil {
+	var t reflect.Type
+	if kind := rv.Elem().Kind(); kind == reflect.Slice || kind == reflect.Array {
+		t = rv.Type().Elem().Elem()
+	}
+	if err := dec.initHeader(t); err != nil {
 		return err
 	}
 
@@ -132,12 +379,24 @@ func (dec *Decoder) DecodeAll(v any) error {
 		typ := elem.Type()
 		typeElem := typ.Elem()
 		newElem := reflect.MakeSlice(typ, 0, 4)
+		var errs DecodeErrors
 		for {
+			if err := dec.checkContext(ctx); err != nil {
+				elem.Set(newElem)
+				return err
+			}
 			ev := reflect.New(typeElem)
 			if err := dec.decodeRecord(ev); err != nil {
+				if dec.collectError(&errs, err) {
+					if dec.tooManyErrors(errs) {
+						elem.Set(newElem)
+						return errs
+					}
+					continue
+				}
 				elem.Set(newElem)
 				if errors.Is(err, io.EOF) {
-					return nil
+					return errs.err()
 				}
 				return err
 			}
@@ -145,21 +404,32 @@ func (dec *Decoder) DecodeAll(v any) error {
 		}
 	case reflect.Array:
 		l := elem.Len()
+		var errs DecodeErrors
 		for i := 0; i < l; i++ {
+			if err := dec.checkContext(ctx); err != nil {
+				return err
+			}
 			ev := elem.Index(i)
 			if err := dec.decodeRecord(ev); err != nil {
 				ev.Set(reflect.Zero(ev.Type()))
+				if dec.collectError(&errs, err) {
+					if dec.tooManyErrors(errs) {
+						return errs
+					}
+					// reuse the element for the next record.
+					i--
+					continue
+				}
 				if errors.Is(err, io.EOF) {
-					return nil
+					return errs.err()
 				}
 				return err
 			}
 		}
+		return errs.err()
 	default:
 		return errors.New("headercsv: v is neither a slice nor an array")
 	}
-
-	return nil
 }
 
 // SetHeader sets the header.
@@ -172,25 +442,194 @@ func (dec *Decoder) SetHeader(header []string) error {
 	return nil
 }
 
-func (dec *Decoder) initHeader() error {
+// Header returns the header.
+// If the header is not set yet, it reads the header from the input.
+func (dec *Decoder) Header() ([]string, error) {
+	if err := dec.initHeader(nil); err != nil {
+		return nil, err
+	}
+	return dec.header, nil
+}
+
+// FieldPos returns the line and column of the field with the given index
+// in the record most recently decoded.
+// Numbering of lines and columns starts at 1; columns are counted in bytes, not runes.
+//
+// If this is called with an out-of-bounds index, it panics.
+func (dec *Decoder) FieldPos(field int) (line, column int) {
+	return dec.r.FieldPos(field)
+}
+
+// Fields returns the number of fields in the record most recently read.
+// It may be less than the number of columns in the header; FieldPos accepts the indexes less than it.
+func (dec *Decoder) Fields() int {
+	return dec.fields
+}
+
+// Records returns the number of records read, excluding the header.
+// It is the Record of the DecodeError reported for the record most recently read.
+func (dec *Decoder) Records() int {
+	return dec.records
+}
+
+func (dec *Decoder) checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return &CanceledError{Records: dec.records, Err: err}
+	}
+	return nil
+}
+
+// initHeader reads the header, skipping the preamble.
+// t is the type of the records, used by DetectHeader; it may be nil.
+func (dec *Decoder) initHeader(t reflect.Type) error {
 	if dec.header != nil {
+		// the header is set by SetHeader; the preamble precedes the records.
+		if err := dec.skipPreamble(); err != nil {
+			return err
+		}
+		dec.initTrailer()
 		return nil
 	}
-	header, err := dec.r.Read()
+
+	var expected map[string]bool
+	if dec.DetectHeader {
+		var err error
+		expected, err = dec.expectedHeader(t)
+		if err != nil {
+			return err
+		}
+	}
+
+	restore := dec.loosen()
+	header, err := dec.readHeader()
 	if err != nil {
+		restore(0)
 		return err
 	}
+	if len(header) > 0 {
+		// remove the byte order mark.
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	for dec.isPreamble(header, expected) {
+		if expected != nil && len(dec.preamble) >= maxPreambleRecords {
+			restore(0)
+			return errors.New("headercsv: the header is not found")
+		}
+		dec.preamble = append(dec.preamble, append([]string(nil), header...))
+		header, err = dec.r.Read()
+		if err != nil {
+			restore(0)
+			if errors.Is(err, io.EOF) && expected != nil {
+				return errors.New("headercsv: the header is not found")
+			}
+			return err
+		}
+	}
+	restore(len(header))
 	dec.header = header
+	dec.initTrailer()
 	return nil
 }
 
 func (dec *Decoder) decodeRecord(v reflect.Value) error {
 	v = dec.indirect(v)
-	record, err := dec.r.Read()
-	if err != nil {
+
+	// keep the original value to restore it when the record is skipped.
+	var orig reflect.Value
+	if dec.ErrorHandler != nil && v.Kind() != reflect.Map && v.CanSet() {
+		orig = reflect.New(v.Type()).Elem()
+		orig.Set(v)
+	}
+
+	for {
+		record, err := dec.r.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			record, err = dec.parseError(parseErr, record, err)
+			if err == errSkipRow {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else {
+			dec.records++
+		}
+		dec.fields = len(record)
+		if dec.excel {
+			unquoteExcelText(record)
+		}
+
+		err = dec.decodeFields(v, record)
+		if err == errSkipRow {
+			if orig.IsValid() {
+				v.Set(orig)
+			}
+			continue
+		}
 		return err
 	}
+}
+
+// parseError handles the record rejected by the CSV parser.
+// record is the partial record returned with csv.ErrFieldCount; it is nil for the other errors.
+// It returns the record to decode, errSkipRow if the record is skipped, or err if decoding should stop.
+func (dec *Decoder) parseError(parseErr *csv.ParseError, record []string, err error) ([]string, error) {
+	if dec.ErrorHandler == nil && !dec.CollectErrors {
+		return nil, err
+	}
+	if !errors.Is(parseErr.Err, csv.ErrFieldCount) {
+		// the fields parsed before the error are incomplete.
+		record = nil
+	}
+	dec.records++
+	decodeErr := &DecodeError{
+		Record:    dec.records,
+		StartLine: parseErr.StartLine,
+		Line:      parseErr.Line,
+		Column:    parseErr.Column,
+		Err:       parseErr.Err,
+	}
+
+	if dec.ErrorHandler != nil {
+		switch dec.ErrorHandler(decodeErr, record) {
+		case SkipRow:
+			return nil, errSkipRow
+		case UseZero:
+			if record == nil {
+				// nothing is parsed; the record is skipped.
+				return nil, errSkipRow
+			}
+			return record, nil
+		}
+	}
 
+	if !dec.CollectErrors {
+		return nil, err
+	}
+	return nil, DecodeErrors{decodeErr}
+}
+
+// recordReader reads records; it is implemented by *csv.Reader.
+type recordReader interface {
+	Read() (record []string, err error)
+	FieldPos(field int) (line, column int)
+}
+
+// errSkipRow is an internal signal to skip the current record.
+var errSkipRow = errors.New("headercsv: skip row")
+
+func (dec *Decoder) decodeFields(v reflect.Value, record []string) error {
+	if u, ok := dec.recordUnmarshaler(v); ok {
+		if err := dec.unmarshalRecord(u, v, record); err != nil {
+			return err
+		}
+		return dec.validateRecord(v, record)
+	}
+
+	var errs DecodeErrors
 	t := v.Type()
 	switch v.Kind() {
 	case reflect.Map:
@@ -201,25 +640,33 @@ func (dec *Decoder) decodeRecord(v reflect.Value) error {
 			v.Set(reflect.MakeMap(t))
 		}
 		elemType := v.Type().Elem()
+		keys := make([]string, 0, len(dec.header))
+		elems := make([]reflect.Value, 0, len(dec.header))
 		for i, k := range dec.header {
 			if i >= len(record) {
 				break
 			}
+			if !dec.columns.selected(k) {
+				continue
+			}
 			elem := reflect.New(elemType).Elem()
-			if err := dec.decodeField(elem, record[i]); err != nil {
-				startLine, _ := dec.r.FieldPos(0)
-				line, col := dec.r.FieldPos(i)
-				return &DecodeError{
-					StartLine: startLine,
-					Line:      line,
-					Column:    col,
-					Field:     k,
-					Err:       err,
+			if err := dec.decodeField(elem, record[i], nil); err != nil {
+				n := len(errs)
+				if err := dec.fieldError(&errs, record, i, k, elem, err); err != nil {
+					return err
+				}
+				if len(errs) > n {
+					// the error is collected; leave the element unset like the fields of structs.
+					continue
 				}
 			}
-			v.SetMapIndex(reflect.ValueOf(k), elem)
+			keys = append(keys, k)
+			elems = append(elems, elem)
 		}
-		return nil
+		for i, elem := range elems {
+			v.SetMapIndex(reflect.ValueOf(keys[i]), elem)
+		}
+		return errs.err()
 
 	case reflect.Slice:
 		v.Set(reflect.MakeSlice(v.Type(), len(dec.header), len(dec.header)))
@@ -228,20 +675,17 @@ func (dec *Decoder) decodeRecord(v reflect.Value) error {
 			if i >= len(record) {
 				break
 			}
+			if !dec.columns.selected(k) {
+				continue
+			}
 			v, _ := rt.Field(v, i, k)
-			if err := dec.decodeField(v, record[i]); err != nil {
-				startLine, _ := dec.r.FieldPos(0)
-				line, col := dec.r.FieldPos(i)
-				return &DecodeError{
-					StartLine: startLine,
-					Line:      line,
-					Column:    col,
-					Field:     k,
-					Err:       err,
+			if err := dec.decodeField(v, record[i], nil); err != nil {
+				if err := dec.fieldError(&errs, record, i, k, v, err); err != nil {
+					return err
 				}
 			}
 		}
-		return nil
+		return errs.err()
 
 	case reflect.Array:
 		rt := recordType(t)
@@ -249,20 +693,17 @@ func (dec *Decoder) decodeRecord(v reflect.Value) error {
 			if i >= len(record) {
 				break
 			}
+			if !dec.columns.selected(k) {
+				continue
+			}
 			v, _ := rt.Field(v, i, k)
-			if err := dec.decodeField(v, record[i]); err != nil {
-				startLine, _ := dec.r.FieldPos(0)
-				line, col := dec.r.FieldPos(i)
-				return &DecodeError{
-					StartLine: startLine,
-					Line:      line,
-					Column:    col,
-					Field:     k,
-					Err:       err,
+			if err := dec.decodeField(v, record[i], nil); err != nil {
+				if err := dec.fieldError(&errs, record, i, k, v, err); err != nil {
+					return err
 				}
 			}
 		}
-		return nil
+		return errs.err()
 
 	case reflect.Interface:
 		if t.NumMethod() > 0 {
@@ -274,6 +715,9 @@ func (dec *Decoder) decodeRecord(v reflect.Value) error {
 			if i >= len(record) {
 				break
 			}
+			if !dec.columns.selected(k) {
+				continue
+			}
 			w.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(record[i]))
 		}
 		v.Set(w)
@@ -281,36 +725,108 @@ func (dec *Decoder) decodeRecord(v reflect.Value) error {
 	}
 
 	rt := recordType(t)
+	if err := recordTypeError(rt); err != nil {
+		return err
+	}
 	for i, k := range dec.header {
 		if i >= len(record) {
 			break
 		}
+		if !dec.columns.selected(k) {
+			continue
+		}
 		v, f := rt.Field(v, i, k)
 		if f != nil {
-			if err := dec.decodeField(v, record[i]); err != nil {
-				startLine, _ := dec.r.FieldPos(0)
-				line, col := dec.r.FieldPos(i)
-				return &DecodeError{
-					StartLine: startLine,
-					Line:      line,
-					Column:    col,
-					Field:     k,
-					Err:       err,
+			err := dec.decodeField(v, record[i], f)
+			if err == nil && f.validation != nil {
+				err = f.validation.validate(v, record[i])
+			}
+			if err != nil {
+				if err := dec.fieldError(&errs, record, i, k, v, err); err != nil {
+					return err
 				}
 			}
 		}
 	}
 
+	return errs.err()
+}
+
+// fieldError handles the error err that occurred while decoding the i-th field named name into v.
+// It returns a non-nil error if decoding the record should stop,
+// otherwise the error is collected into errs or ignored by the ErrorHandler.
+func (dec *Decoder) fieldError(errs *DecodeErrors, record []string, i int, name string, v reflect.Value, err error) error {
+	startLine, _ := dec.r.FieldPos(0)
+	line, col := dec.r.FieldPos(i)
+	decodeErr := &DecodeError{
+		Record:    dec.records,
+		StartLine: startLine,
+		Line:      line,
+		Column:    col,
+		Field:     name,
+		Value:     record[i],
+		Type:      v.Type(),
+		Err:       err,
+	}
+
+	if dec.ErrorHandler != nil {
+		switch dec.ErrorHandler(decodeErr, record) {
+		case SkipRow:
+			return errSkipRow
+		case UseZero:
+			if v.CanSet() {
+				v.Set(reflect.Zero(v.Type()))
+			}
+			return nil
+		}
+	}
+
+	if !dec.CollectErrors {
+		return decodeErr
+	}
+	*errs = append(*errs, decodeErr)
 	return nil
 }
 
-func (dec *Decoder) decodeField(v reflect.Value, field string) error {
+// collectError reports whether err has been collected into errs in the collect-all-errors mode.
+func (dec *Decoder) collectError(errs *DecodeErrors, err error) bool {
+	if !dec.CollectErrors {
+		return false
+	}
+	var recordErrs DecodeErrors
+	if !errors.As(err, &recordErrs) {
+		return false
+	}
+	*errs = append(*errs, recordErrs...)
+	if dec.MaxErrors > 0 && len(*errs) > dec.MaxErrors {
+		*errs = (*errs)[:dec.MaxErrors]
+	}
+	return true
+}
+
+// tooManyErrors reports whether errs has reached the limit of the collect-all-errors mode.
+func (dec *Decoder) tooManyErrors(errs DecodeErrors) bool {
+	return dec.MaxErrors > 0 && len(errs) >= dec.MaxErrors
+}
+
+func (dec *Decoder) decodeField(v reflect.Value, field string, opt *field) error {
 	if field == "" && v.Kind() == reflect.Pointer {
 		v.Set(reflect.Zero(v.Type()))
 		return nil
 	}
-	u, v := dec.indirectField(v)
+	u, s, v := dec.indirectField(v)
+	if s != nil {
+		return scanField(s, field, opt)
+	}
 	if u != nil {
+		if t, ok := u.(*time.Time); ok && opt != nil && opt.layout != "" {
+			parsed, err := time.Parse(opt.layout, field)
+			if err != nil {
+				return err
+			}
+			*t = parsed
+			return nil
+		}
 		return u.UnmarshalText([]byte(field))
 	}
 	if !v.CanSet() {
@@ -378,7 +894,10 @@ func (dec *Decoder) indirect(v reflect.Value) reflect.Value {
 	return v
 }
 
-func (dec *Decoder) indirectField(v reflect.Value) (encoding.TextUnmarshaler, reflect.Value) {
+// indirectField allocates the pointers of v and returns the encoding.TextUnmarshaler or the sql.Scanner that decodes the field.
+// If neither is found, it returns the value that the field is decoded into.
+// encoding.TextUnmarshaler takes precedence over sql.Scanner.
+func (dec *Decoder) indirectField(v reflect.Value) (encoding.TextUnmarshaler, sql.Scanner, reflect.Value) {
 	if v.Kind() != reflect.Pointer && v.Type().Name() != "" && v.CanAddr() {
 		v = v.Addr()
 	}
@@ -388,10 +907,37 @@ func (dec *Decoder) indirectField(v reflect.Value) (encoding.TextUnmarshaler, re
 		}
 		if v.Type().NumMethod() > 0 {
 			if u, ok := v.Interface().(encoding.TextUnmarshaler); ok {
-				return u, reflect.Value{}
+				return u, nil, reflect.Value{}
+			}
+			if s, ok := v.Interface().(sql.Scanner); ok {
+				return nil, s, reflect.Value{}
 			}
 		}
 		v = v.Elem()
 	}
-	return nil, v
+	return nil, nil, v
+}
+
+// scanField decodes the field by the sql.Scanner s.
+// An empty field is scanned as NULL.
+func scanField(s sql.Scanner, field string, opt *field) error {
+	if field == "" {
+		return s.Scan(nil)
+	}
+	if opt != nil && opt.layout != "" {
+		t, err := time.Parse(opt.layout, field)
+		if err != nil {
+			return err
+		}
+		return s.Scan(t)
+	}
+	err := s.Scan(field)
+	if err == nil {
+		return nil
+	}
+	// the scanners of time values, such as sql.NullTime, don't accept strings.
+	if t, terr := time.Parse(time.RFC3339Nano, field); terr == nil && s.Scan(t) == nil {
+		return nil
+	}
+	return err
 }
@@ -0,0 +1,28 @@
+//go:build go1.20
+
+package headercsv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestDecodeErrors_join(t *testing.T) {
+	d := NewDecoder(bytes.NewBufferString("a\nx\n"))
+	d.CollectErrors = true
+	var v []map[string]int
+	err := errors.Join(d.DecodeAll(&v), io.ErrUnexpectedEOF)
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatal("want DecodeError, but none")
+	}
+	if decodeErr.Field != "a" {
+		t.Errorf("got %q, want %q", decodeErr.Field, "a")
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Error("want io.ErrUnexpectedEOF, but none")
+	}
+}
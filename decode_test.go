@@ -2,6 +2,9 @@ package headercsv
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"io"
 	"reflect"
@@ -742,3 +745,585 @@ func TestDecodeAll_error(t *testing.T) {
 		}
 	})
 }
+
+// cancelUnmarshal cancels cancelDecoding when it is decoded.
+type cancelUnmarshal string
+
+var cancelDecoding context.CancelFunc
+
+func (c *cancelUnmarshal) UnmarshalText(data []byte) error {
+	*c = cancelUnmarshal(data)
+	if string(data) == "cancel" {
+		cancelDecoding()
+	}
+	return nil
+}
+
+func TestDecodeAllContext(t *testing.T) {
+	t.Run("canceled before decoding", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		d := NewDecoder(bytes.NewBufferString("a\nb\n"))
+		var v []map[string]string
+		err := d.DecodeAllContext(ctx, &v)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("want context.Canceled, got %v", err)
+		}
+		var canceledErr *CanceledError
+		if !errors.As(err, &canceledErr) {
+			t.Fatal("want CanceledError, but none")
+		}
+		if canceledErr.Records != 0 {
+			t.Errorf("got %d, want 0", canceledErr.Records)
+		}
+	})
+
+	t.Run("canceled while decoding", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cancelDecoding = cancel
+
+		d := NewDecoder(bytes.NewBufferString("a\nfoo\ncancel\nbar\n"))
+		var v []struct {
+			A cancelUnmarshal `csv:"a"`
+		}
+		err := d.DecodeAllContext(ctx, &v)
+		var canceledErr *CanceledError
+		if !errors.As(err, &canceledErr) {
+			t.Fatalf("want CanceledError, got %v", err)
+		}
+		if canceledErr.Records != 2 {
+			t.Errorf("got %d, want 2", canceledErr.Records)
+		}
+		if len(v) != 2 {
+			t.Fatalf("got %d records, want 2", len(v))
+		}
+		if v[0].A != "foo" || v[1].A != "cancel" {
+			t.Errorf("unexpected records: %#v", v)
+		}
+	})
+
+	t.Run("canceled while decoding into an array", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cancelDecoding = cancel
+
+		d := NewDecoder(bytes.NewBufferString("a\ncancel\nbar\n"))
+		var v [2]struct {
+			A cancelUnmarshal `csv:"a"`
+		}
+		err := d.DecodeAllContext(ctx, &v)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+		if v[0].A != "cancel" || v[1].A != "" {
+			t.Errorf("unexpected records: %#v", v)
+		}
+	})
+}
+
+func TestDecodeRecordContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := NewDecoder(bytes.NewBufferString("a\nb\nc\n"))
+	var v map[string]string
+	if err := d.DecodeRecordContext(ctx, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v["a"] != "b" {
+		t.Errorf("got %q, want %q", v["a"], "b")
+	}
+
+	cancel()
+	err := d.DecodeRecordContext(ctx, &v)
+	var canceledErr *CanceledError
+	if !errors.As(err, &canceledErr) {
+		t.Fatalf("want CanceledError, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+	if canceledErr.Records != 1 {
+		t.Errorf("got %d, want 1", canceledErr.Records)
+	}
+}
+
+func TestDecodeAll_collectErrors(t *testing.T) {
+	type record struct {
+		A int `csv:"a"`
+		B int `csv:"b"`
+	}
+
+	t.Run("slice", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,x\ny,z\n3,4\n"))
+		d.CollectErrors = true
+		var v []record
+		err := d.DecodeAll(&v)
+		var errs DecodeErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("want DecodeErrors, got %v", err)
+		}
+		if !reflect.DeepEqual(v, []record{{3, 4}}) {
+			t.Errorf("got %#v, want %#v", v, []record{{3, 4}})
+		}
+		if len(errs) != 3 {
+			t.Fatalf("got %d errors, want 3", len(errs))
+		}
+		want := []struct {
+			line  int
+			field string
+		}{
+			{2, "b"}, {3, "a"}, {3, "b"},
+		}
+		for i, w := range want {
+			if errs[i].Line != w.line {
+				t.Errorf("errs[%d]: got line %d, want %d", i, errs[i].Line, w.line)
+			}
+			if errs[i].Field != w.field {
+				t.Errorf("errs[%d]: got field %q, want %q", i, errs[i].Field, w.field)
+			}
+		}
+
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatal("want DecodeError, but none")
+		}
+		if decodeErr != errs[0] {
+			t.Errorf("got %v, want %v", decodeErr, errs[0])
+		}
+		if !errors.Is(err, strconv.ErrSyntax) {
+			t.Errorf("want strconv.ErrSyntax, got %v", err)
+		}
+	})
+
+	t.Run("array", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,x\n2,3\n"))
+		d.CollectErrors = true
+		var v [2]record
+		err := d.DecodeAll(&v)
+		var errs DecodeErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("want DecodeErrors, got %v", err)
+		}
+		if len(errs) != 1 {
+			t.Errorf("got %d errors, want 1", len(errs))
+		}
+		if !reflect.DeepEqual(v, [2]record{{2, 3}, {}}) {
+			t.Errorf("got %#v, want %#v", v, [2]record{{2, 3}, {}})
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,2\nx,y\nz,3\n4,5\n"))
+		d.CollectErrors = true
+		d.MaxErrors = 2
+		var v []record
+		err := d.DecodeAll(&v)
+		var errs DecodeErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("want DecodeErrors, got %v", err)
+		}
+		if len(errs) != 2 {
+			t.Errorf("got %d errors, want 2", len(errs))
+		}
+		if !reflect.DeepEqual(v, []record{{1, 2}}) {
+			t.Errorf("got %#v, want %#v", v, []record{{1, 2}})
+		}
+	})
+
+	t.Run("parse errors", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,2\n3\n4,x\n5,6\"\n7,8\n"))
+		d.CollectErrors = true
+		var v []record
+		err := d.DecodeAll(&v)
+		var errs DecodeErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("want DecodeErrors, got %v", err)
+		}
+		if !reflect.DeepEqual(v, []record{{1, 2}, {7, 8}}) {
+			t.Errorf("got %#v, want %#v", v, []record{{1, 2}, {7, 8}})
+		}
+		want := []struct {
+			record int
+			line   int
+			field  string
+			err    error
+		}{
+			{2, 3, "", csv.ErrFieldCount},
+			{3, 4, "b", strconv.ErrSyntax},
+			{4, 5, "", csv.ErrBareQuote},
+		}
+		if len(errs) != len(want) {
+			t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+		}
+		for i, w := range want {
+			if errs[i].Record != w.record || errs[i].Line != w.line || errs[i].Field != w.field || !errors.Is(errs[i], w.err) {
+				t.Errorf("errs[%d]: got %v (record %d), want record %d, line %d, field %q, %v", i, errs[i], errs[i].Record, w.record, w.line, w.field, w.err)
+			}
+		}
+	})
+
+	t.Run("no errors", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,2\n"))
+		d.CollectErrors = true
+		var v []record
+		if err := d.DecodeAll(&v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, []record{{1, 2}}) {
+			t.Errorf("got %#v, want %#v", v, []record{{1, 2}})
+		}
+	})
+
+	t.Run("record", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\nx,y\n"))
+		d.CollectErrors = true
+		var v map[string]int
+		err := d.DecodeRecord(&v)
+		var errs DecodeErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("want DecodeErrors, got %v", err)
+		}
+		if len(errs) != 2 {
+			t.Errorf("got %d errors, want 2", len(errs))
+		}
+		if len(v) != 0 {
+			t.Errorf("got %#v, want no fields", v)
+		}
+	})
+
+	t.Run("map", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,x\n"))
+		d.CollectErrors = true
+		v := map[string]int{"b": 5}
+		err := d.DecodeRecord(&v)
+		var errs DecodeErrors
+		if !errors.As(err, &errs) {
+			t.Fatalf("want DecodeErrors, got %v", err)
+		}
+		if !reflect.DeepEqual(v, map[string]int{"a": 1, "b": 5}) {
+			t.Errorf("got %#v", v)
+		}
+	})
+}
+
+func TestDecodeAll_errorHandler(t *testing.T) {
+	type record struct {
+		A int `csv:"a"`
+		B int `csv:"b"`
+	}
+
+	t.Run("skip row", func(t *testing.T) {
+		var rejected [][]string
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,2\n3,x\n5,6\n"))
+		d.ErrorHandler = func(err *DecodeError, raw []string) Action {
+			rejected = append(rejected, append([]string(nil), raw...))
+			return SkipRow
+		}
+		var v []record
+		if err := d.DecodeAll(&v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, []record{{1, 2}, {5, 6}}) {
+			t.Errorf("got %#v, want %#v", v, []record{{1, 2}, {5, 6}})
+		}
+		if !reflect.DeepEqual(rejected, [][]string{{"3", "x"}}) {
+			t.Errorf("got %#v, want %#v", rejected, [][]string{{"3", "x"}})
+		}
+	})
+
+	t.Run("skip row in map", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,x\n3,4\n"))
+		d.ErrorHandler = func(err *DecodeError, raw []string) Action {
+			return SkipRow
+		}
+		v := map[string]int{"c": 5}
+		if err := d.DecodeRecord(&v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, map[string]int{"a": 3, "b": 4, "c": 5}) {
+			t.Errorf("got %#v", v)
+		}
+	})
+
+	t.Run("skip row in struct", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,x\n"))
+		d.ErrorHandler = func(err *DecodeError, raw []string) Action {
+			return SkipRow
+		}
+		v := record{A: 10, B: 20}
+		err := d.DecodeRecord(&v)
+		if err != io.EOF {
+			t.Errorf("want io.EOF, got %v", err)
+		}
+		if v != (record{A: 10, B: 20}) {
+			t.Errorf("got %#v, want the original value", v)
+		}
+	})
+
+	t.Run("skip row with pointer", func(t *testing.T) {
+		type ptrRecord struct {
+			A *int `csv:"a"`
+			B int  `csv:"b"`
+		}
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,x\n"))
+		d.ErrorHandler = func(err *DecodeError, raw []string) Action {
+			return SkipRow
+		}
+		a := 10
+		v := ptrRecord{A: &a, B: 20}
+		err := d.DecodeRecord(&v)
+		if err != io.EOF {
+			t.Errorf("want io.EOF, got %v", err)
+		}
+		if v.A != &a || v.B != 20 {
+			t.Errorf("got %#v, want the original value", v)
+		}
+		// the pointee is decoded in place, and it isn't restored.
+		if a != 1 {
+			t.Errorf("got %d, want 1", a)
+		}
+	})
+
+	t.Run("use zero", func(t *testing.T) {
+		var fields []string
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,2\nx,4\n5,y\n"))
+		d.ErrorHandler = func(err *DecodeError, raw []string) Action {
+			fields = append(fields, err.Field)
+			return UseZero
+		}
+		var v []record
+		if err := d.DecodeAll(&v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, []record{{1, 2}, {0, 4}, {5, 0}}) {
+			t.Errorf("got %#v", v)
+		}
+		if !reflect.DeepEqual(fields, []string{"a", "b"}) {
+			t.Errorf("got %#v, want %#v", fields, []string{"a", "b"})
+		}
+	})
+
+	t.Run("use zero in map", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\nx,4\n"))
+		d.ErrorHandler = func(err *DecodeError, raw []string) Action {
+			return UseZero
+		}
+		var v []map[string]int
+		if err := d.DecodeAll(&v); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, []map[string]int{{"a": 0, "b": 4}}) {
+			t.Errorf("got %#v", v)
+		}
+	})
+
+	t.Run("skip rows rejected by the parser", func(t *testing.T) {
+		type textRecord struct {
+			A string `csv:"a"`
+			B string `csv:"b"`
+		}
+		var rejected [][]string
+		var errs []*DecodeError
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,x\n2\n3,z\n4,a\"b\n5,w\n"))
+		d.ErrorHandler = func(err *DecodeError, raw []string) Action {
+			rejected = append(rejected, append([]string(nil), raw...))
+			errs = append(errs, err)
+			return SkipRow
+		}
+		var v []textRecord
+		if err := d.DecodeAll(&v); err != nil {
+			t.Fatal(err)
+		}
+		want := []textRecord{{"1", "x"}, {"3", "z"}, {"5", "w"}}
+		if !reflect.DeepEqual(v, want) {
+			t.Errorf("got %#v, want %#v", v, want)
+		}
+		if !reflect.DeepEqual(rejected, [][]string{{"2"}, nil}) {
+			t.Errorf("got %#v, want %#v", rejected, [][]string{{"2"}, nil})
+		}
+		if len(errs) != 2 {
+			t.Fatalf("got %d errors, want 2", len(errs))
+		}
+		if !errors.Is(errs[0], csv.ErrFieldCount) || errs[0].Record != 2 || errs[0].Line != 3 {
+			t.Errorf("unexpected error: %#v", errs[0])
+		}
+		if !errors.Is(errs[1], csv.ErrBareQuote) || errs[1].Record != 4 || errs[1].Line != 5 {
+			t.Errorf("unexpected error: %#v", errs[1])
+		}
+	})
+
+	t.Run("use zero for the wrong number of fields", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,2\n3\n4,\"x\"y\n"))
+		d.ErrorHandler = func(err *DecodeError, raw []string) Action {
+			return UseZero
+		}
+		var v []record
+		if err := d.DecodeAll(&v); err != nil {
+			t.Fatal(err)
+		}
+		// the partial record is decoded, and the record that isn't parsed is skipped.
+		if !reflect.DeepEqual(v, []record{{1, 2}, {3, 0}}) {
+			t.Errorf("got %#v", v)
+		}
+	})
+
+	t.Run("abort on the wrong number of fields", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,2\n3\n"))
+		d.ErrorHandler = func(err *DecodeError, raw []string) Action {
+			return Abort
+		}
+		var v []record
+		err := d.DecodeAll(&v)
+		var parseErr *csv.ParseError
+		if !errors.As(err, &parseErr) || !errors.Is(err, csv.ErrFieldCount) {
+			t.Errorf("want csv.ParseError, got %v", err)
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBufferString("a,b\n1,2\nx,4\n5,6\n"))
+		d.ErrorHandler = func(err *DecodeError, raw []string) Action {
+			return Abort
+		}
+		var v []record
+		err := d.DecodeAll(&v)
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("want DecodeError, got %v", err)
+		}
+		if decodeErr.Line != 3 {
+			t.Errorf("got %d, want 3", decodeErr.Line)
+		}
+		if !reflect.DeepEqual(v, []record{{1, 2}}) {
+			t.Errorf("got %#v", v)
+		}
+	})
+}
+
+func TestDecodeError_details(t *testing.T) {
+	in := "name,price\napple,100\norange,\"12,5\"\n"
+	d := NewDecoder(bytes.NewBufferString(in))
+	var v []struct {
+		Name  string  `csv:"name"`
+		Price float64 `csv:"price"`
+	}
+	err := d.DecodeAll(&v)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("want DecodeError, got %v", err)
+	}
+	if decodeErr.Record != 2 {
+		t.Errorf("got %d, want 2", decodeErr.Record)
+	}
+	if decodeErr.Field != "price" {
+		t.Errorf("got %q, want %q", decodeErr.Field, "price")
+	}
+	if decodeErr.Value != "12,5" {
+		t.Errorf("got %q, want %q", decodeErr.Value, "12,5")
+	}
+	if decodeErr.Type != reflect.TypeOf(float64(0)) {
+		t.Errorf("got %v, want float64", decodeErr.Type)
+	}
+
+	got := decodeErr.Snippet("orange,\"12,5\"\n")
+	want := "3 | orange,\"12,5\"\n  |        ^"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	data, err := json.Marshal(decodeErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `{"record":2,"start_line":3,"line":3,"column":8,"field":"price","value":"12,5","type":"float64","error":"strconv.ParseFloat: parsing \"12,5\": invalid syntax"}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestDecodeError_Snippet(t *testing.T) {
+	testcases := []struct {
+		err  *DecodeError
+		line string
+		want string
+	}{
+		{
+			&DecodeError{Line: 1, Column: 1},
+			"abc",
+			"1 | abc\n  | ^",
+		},
+		{
+			&DecodeError{Line: 10, Column: 3},
+			"\tb,c\r\n",
+			"10 | \tb,c\n   | \t ^",
+		},
+		{
+			&DecodeError{Line: 2, Column: 10},
+			"abc",
+			"2 | abc\n  |    ^",
+		},
+	}
+	for _, tc := range testcases {
+		got := tc.err.Snippet(tc.line)
+		if got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestDecoder_FieldPos(t *testing.T) {
+	dec := NewDecoder(bytes.NewBufferString("a,b\n1,\"x\ny\"\n22,z\n"))
+	var record []string
+	testcases := []struct {
+		line, column int
+	}{
+		{2, 3},
+		{4, 4},
+	}
+	for _, tc := range testcases {
+		if err := dec.DecodeRecord(&record); err != nil {
+			t.Fatal(err)
+		}
+		line, column := dec.FieldPos(1)
+		if line != tc.line || column != tc.column {
+			t.Errorf("%q: got line %d, column %d, want line %d, column %d", record, line, column, tc.line, tc.column)
+		}
+	}
+}
+
+func TestDecoder_Records(t *testing.T) {
+	dec := NewDecoder(bytes.NewBufferString("a\n1\nx\n"))
+	var record []int
+	if err := dec.DecodeRecord(&record); err != nil {
+		t.Fatal(err)
+	}
+	if got := dec.Records(); got != 1 {
+		t.Errorf("got %d, want 1", got)
+	}
+	var decodeErr *DecodeError
+	if err := dec.DecodeRecord(&record); !errors.As(err, &decodeErr) {
+		t.Fatalf("want DecodeError, got %v", err)
+	}
+	if got := dec.Records(); got != decodeErr.Record {
+		t.Errorf("got %d, want %d", got, decodeErr.Record)
+	}
+}
+
+func TestDecoder_Fields(t *testing.T) {
+	r := csv.NewReader(bytes.NewBufferString("a,b\n1\n"))
+	r.FieldsPerRecord = -1
+	dec := NewDecoderCSV(r)
+	var record []string
+	if err := dec.DecodeRecord(&record); err != nil {
+		t.Fatal(err)
+	}
+	if len(record) != 2 {
+		t.Errorf("got %d values, want 2", len(record))
+	}
+	if got := dec.Fields(); got != 1 {
+		t.Errorf("got %d fields, want 1", got)
+	}
+}
@@ -0,0 +1,296 @@
+package headercsv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Dialect describes the format of CSV text.
+type Dialect struct {
+	// Comma is the field delimiter. If it is zero, ',' is used.
+	Comma rune
+
+	// Quote is the quote character. If it is zero, '"' is used.
+	// Only '"' is supported because encoding/csv doesn't support other quote characters.
+	Quote rune
+
+	// Comment is the comment character.
+	// Lines beginning with it are ignored on reading. If it is zero, no comment is supported.
+	Comment rune
+
+	// TrimLeadingSpace is true if the leading white space in fields is ignored on reading.
+	TrimLeadingSpace bool
+
+	// LineTerminator is the line terminator on writing; "\n" or "\r\n".
+	// If it is empty, "\n" is used. Both of them are accepted on reading.
+	LineTerminator string
+}
+
+// Validate reports whether the dialect is supported.
+func (d *Dialect) Validate() error {
+	comma := d.comma()
+	if !validDelim(comma) {
+		return fmt.Errorf("headercsv: invalid delimiter %q", comma)
+	}
+	if d.Quote != 0 && d.Quote != '"' {
+		return fmt.Errorf("headercsv: unsupported quote character %q", d.Quote)
+	}
+	if d.Comment != 0 && (!validDelim(d.Comment) || d.Comment == comma) {
+		return fmt.Errorf("headercsv: invalid comment character %q", d.Comment)
+	}
+	switch d.LineTerminator {
+	case "", "\n", "\r\n":
+	default:
+		return fmt.Errorf("headercsv: unsupported line terminator %q", d.LineTerminator)
+	}
+	return nil
+}
+
+func (d *Dialect) comma() rune {
+	if d.Comma == 0 {
+		return ','
+	}
+	return d.Comma
+}
+
+func validDelim(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+// NewReader returns a csv.Reader that reads from r in the dialect.
+// The input is converted into UTF-8 by NewUTF8Reader.
+func (d *Dialect) NewReader(r io.Reader) (*csv.Reader, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	cr := newCSVReader(r)
+	cr.Comma = d.comma()
+	cr.Comment = d.Comment
+	cr.TrimLeadingSpace = d.TrimLeadingSpace
+	return cr, nil
+}
+
+// NewWriter returns a csv.Writer that writes to w in the dialect.
+func (d *Dialect) NewWriter(w io.Writer) (*csv.Writer, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = d.comma()
+	cw.UseCRLF = d.LineTerminator == "\r\n"
+	return cw, nil
+}
+
+// NewDecoderDialect returns a new decoder that reads from r in the dialect d.
+func NewDecoderDialect(r io.Reader, d *Dialect) (*Decoder, error) {
+	cr, err := d.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewDecoderCSV(cr), nil
+}
+
+// NewEncoderDialect returns a new encoder that writes to w in the dialect d.
+func NewEncoderDialect(w io.Writer, d *Dialect) (*Encoder, error) {
+	cw, err := d.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncoderCSV(cw), nil
+}
+
+// sniffSampleSize is the maximum number of bytes that Sniff reads.
+const sniffSampleSize = 64 * 1024
+
+// sniffDelimiters are the delimiter candidates of Sniff, in order of preference.
+var sniffDelimiters = []rune{',', ';', '\t', '|'}
+
+// ErrSniff is returned by Sniff when it cannot detect the dialect.
+var ErrSniff = errors.New("headercsv: could not determine the delimiter")
+
+// Sniff reads a sample of up to 64 KiB from r and guesses the dialect of the CSV text,
+// similar to the csv.Sniffer of Python.
+// The delimiter is detected among ',', ';', '\t' and '|',
+// choosing the one that splits the most rows into the same number of fields.
+// hasHeader reports whether the first row looks like a header;
+// it is a header if its values don't look like the values in the rows below.
+//
+// Sniff consumes the sample from r.
+// To decode the same input, read it into a buffer first, or use the Peek method of bufio.Reader.
+func Sniff(r io.Reader) (d *Dialect, hasHeader bool, err error) {
+	sample, err := io.ReadAll(io.LimitReader(NewUTF8Reader(r), sniffSampleSize))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(sample) == sniffSampleSize {
+		// the last line may be truncated.
+		if i := bytes.LastIndexByte(sample, '\n'); i >= 0 {
+			sample = sample[:i+1]
+		}
+	}
+
+	var best []sniffedRow
+	var bestComma rune
+	var bestScore sniffScore
+	for _, comma := range sniffDelimiters {
+		rows := sniffRows(sample, comma)
+		score := scoreRows(rows)
+		if score.fields < 2 {
+			continue
+		}
+		if best == nil || score.better(bestScore) {
+			best, bestComma, bestScore = rows, comma, score
+		}
+	}
+	if best == nil {
+		return nil, false, ErrSniff
+	}
+
+	d = &Dialect{
+		Comma:            bestComma,
+		TrimLeadingSpace: sniffLeadingSpace(best),
+	}
+	if bytes.Contains(sample, []byte("\r\n")) {
+		d.LineTerminator = "\r\n"
+	}
+	return d, sniffHeader(best, bestScore.fields), nil
+}
+
+type sniffedRow []string
+
+// sniffRows parses the sample with the delimiter comma.
+// It stops at the first parse error.
+func sniffRows(sample []byte, comma rune) []sniffedRow {
+	cr := csv.NewReader(bytes.NewReader(sample))
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	var rows []sniffedRow
+	for {
+		record, err := cr.Read()
+		if err != nil {
+			break
+		}
+		rows = append(rows, record)
+	}
+	return rows
+}
+
+type sniffScore struct {
+	consistent int // the number of the rows that have the most common number of fields
+	total      int // the number of the rows
+	fields     int // the most common number of fields
+}
+
+func scoreRows(rows []sniffedRow) sniffScore {
+	counts := map[int]int{}
+	var score sniffScore
+	for _, row := range rows {
+		n := len(row)
+		counts[n]++
+		if counts[n] > score.consistent || (counts[n] == score.consistent && n > score.fields) {
+			score.consistent, score.fields = counts[n], n
+		}
+	}
+	score.total = len(rows)
+	return score
+}
+
+// better reports whether s is better than t.
+func (s sniffScore) better(t sniffScore) bool {
+	// compare the ratios of the consistent rows: s.consistent/s.total vs t.consistent/t.total
+	a, b := s.consistent*t.total, t.consistent*s.total
+	if a != b {
+		return a > b
+	}
+	return s.fields > t.fields
+}
+
+// sniffLeadingSpace reports whether all fields following a delimiter begin with a space.
+func sniffLeadingSpace(rows []sniffedRow) bool {
+	found := false
+	for _, row := range rows {
+		for _, field := range row[1:] {
+			if field == "" {
+				continue
+			}
+			if field[0] != ' ' {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// sniffRowsForHeader is the maximum number of rows that sniffHeader examines.
+const sniffRowsForHeader = 20
+
+// sniffHeader guesses whether the first row is a header.
+// Each column of the rows below votes: if all of the values are numbers or have the same length,
+// the column votes for a header when the first row's value doesn't match it, and against otherwise.
+func sniffHeader(rows []sniffedRow, fields int) bool {
+	if len(rows) < 2 || len(rows[0]) != fields {
+		return false
+	}
+	header := rows[0]
+	body := rows[1:]
+	if len(body) > sniffRowsForHeader {
+		body = body[:sniffRowsForHeader]
+	}
+
+	votes := 0
+	for i, name := range header {
+		numeric, length := true, -1
+		for _, row := range body {
+			if len(row) != fields {
+				continue
+			}
+			if !isNumber(row[i]) {
+				numeric = false
+			}
+			n := utf8.RuneCountInString(row[i])
+			if length == -1 {
+				length = n
+			} else if length != n {
+				length = -2
+			}
+		}
+		switch {
+		case length == -1:
+			// no consistent rows.
+		case numeric:
+			if isNumber(name) {
+				votes--
+			} else {
+				votes++
+			}
+		case length >= 0:
+			if utf8.RuneCountInString(name) == length {
+				votes--
+			} else {
+				votes++
+			}
+		}
+	}
+	return votes > 0
+}
+
+// isNumber reports whether s looks like a number.
+// The decimal comma, such as "1,5", is also accepted.
+func isNumber(s string) bool {
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.Count(s, ",") == 1 && !strings.Contains(s, ".") {
+		_, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+		return err == nil
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package headercsv
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDialect_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		valid   bool
+	}{
+		{"zero", Dialect{}, true},
+		{"tab", Dialect{Comma: '\t', Comment: '#', LineTerminator: "\r\n"}, true},
+		{"quote delimiter", Dialect{Comma: '"'}, false},
+		{"newline delimiter", Dialect{Comma: '\n'}, false},
+		{"single quote", Dialect{Quote: '\''}, false},
+		{"comment equals delimiter", Dialect{Comma: ';', Comment: ';'}, false},
+		{"bad line terminator", Dialect{LineTerminator: "\r"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.dialect.Validate()
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid %t", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDialect_RoundTrip(t *testing.T) {
+	type row struct {
+		Name string `csv:"name"`
+		Qty  int    `csv:"qty"`
+	}
+	d := &Dialect{Comma: ';', Comment: '#', LineTerminator: "\r\n"}
+
+	var buf bytes.Buffer
+	enc, err := NewEncoderDialect(&buf, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := []row{{"a;b", 1}, {"c", 2}}
+	if err := enc.EncodeAll(in); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if err := enc.Error(); err != nil {
+		t.Fatal(err)
+	}
+	want := "name;qty\r\n\"a;b\";1\r\nc;2\r\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	dec, err := NewDecoderDialect(strings.NewReader("# comment\n"+buf.String()), d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []row
+	if err := dec.DecodeAll(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("got %v, want %v", out, in)
+	}
+
+	if _, err := NewDecoderDialect(strings.NewReader(""), &Dialect{Quote: '\''}); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      Dialect
+		hasHeader bool
+	}{
+		{
+			name:      "comma",
+			input:     "name,qty\napple,1\norange,20\n",
+			want:      Dialect{Comma: ','},
+			hasHeader: true,
+		},
+		{
+			name:      "semicolon with decimal comma",
+			input:     "name;price\napple;1,5\norange;20,25\n",
+			want:      Dialect{Comma: ';'},
+			hasHeader: true,
+		},
+		{
+			name:      "tab with commas in values",
+			input:     "id\tnote\r\n1\ta, b\r\n2\tc\r\n",
+			want:      Dialect{Comma: '\t', LineTerminator: "\r\n"},
+			hasHeader: true,
+		},
+		{
+			name:      "pipe",
+			input:     "1|2|3\n4|5|6\n",
+			want:      Dialect{Comma: '|'},
+			hasHeader: false,
+		},
+		{
+			name:      "leading space",
+			input:     "code, name\nAB, apple\nCD, orange\n",
+			want:      Dialect{Comma: ',', TrimLeadingSpace: true},
+			hasHeader: true,
+		},
+		{
+			name:      "no header",
+			input:     "apple,1\norange,2\nbanana,3\n",
+			want:      Dialect{Comma: ','},
+			hasHeader: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, hasHeader, err := Sniff(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*d, tt.want) {
+				t.Errorf("dialect: got %+v, want %+v", *d, tt.want)
+			}
+			if hasHeader != tt.hasHeader {
+				t.Errorf("hasHeader: got %t, want %t", hasHeader, tt.hasHeader)
+			}
+		})
+	}
+}
+
+func TestSniff_Error(t *testing.T) {
+	_, _, err := Sniff(strings.NewReader("one\ntwo\n"))
+	if !errors.Is(err, ErrSniff) {
+		t.Errorf("want ErrSniff, got %v", err)
+	}
+}
+
+func TestSniff_LargeSample(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("id;name\n")
+	for sb.Len() < sniffSampleSize+100 {
+		sb.WriteString("12345;some long name\n")
+	}
+	d, hasHeader, err := Sniff(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Comma != ';' || !hasHeader {
+		t.Errorf("got %+v, %t", *d, hasHeader)
+	}
+}
@@ -0,0 +1,235 @@
+package headercsv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+)
+
+// maxBufferedRecords is the number of records that the header discovery keeps in memory.
+// The records over the limit are spilled to a temporary file.
+var maxBufferedRecords = 4096
+
+// headerDiscovery buffers records until the header is decided.
+type headerDiscovery struct {
+	header  []string
+	index   map[string]int
+	records []discoveredRecord
+	limit   int // DiscoverHeader when the discovery started
+
+	// temporary file for spilled records.
+	// each record is stored as the number of fields followed by the field names and the values;
+	// the numbers and the lengths of the strings are encoded as uvarints so that the records round-trip exactly.
+	file *os.File
+	w    *bufio.Writer
+}
+
+type discoveredRecord struct {
+	names  []string
+	values []string
+}
+
+// discover buffers the record v whose fields are names, and updates the header.
+func (enc *Encoder) discover(rt recordInterface, v reflect.Value, names []string) error {
+	values, err := enc.encodeFields(rt, v, names)
+	if err != nil {
+		return err
+	}
+
+	if enc.discovery == nil {
+		enc.discovery = &headerDiscovery{
+			index: map[string]int{},
+			limit: enc.DiscoverHeader,
+		}
+	}
+	d := enc.discovery
+	if err := d.add(names, values); err != nil {
+		return err
+	}
+	enc.records++
+
+	if d.limit > 0 && enc.records >= d.limit {
+		return enc.endDiscovery()
+	}
+	return nil
+}
+
+// endDiscovery writes the discovered header and the buffered records.
+func (enc *Encoder) endDiscovery() error {
+	if enc.discovery == nil {
+		return nil
+	}
+	header, err := enc.columns.apply(enc.discovery.header, false)
+	if err != nil {
+		return err
+	}
+	return enc.setHeader(header, true)
+}
+
+// writeDiscovered writes the buffered records with the current header.
+func (enc *Encoder) writeDiscovered() error {
+	d := enc.discovery
+	enc.discovery = nil
+	defer d.close()
+
+	index := make(map[string]int, len(enc.header))
+	for i, name := range enc.header {
+		index[name] = i
+	}
+	return d.each(func(names, values []string) error {
+		record := make([]string, len(enc.header))
+		for i, name := range names {
+			if j, ok := index[name]; ok {
+				record[j] = values[i]
+			}
+		}
+		return enc.w.Write(record)
+	})
+}
+
+func (d *headerDiscovery) add(names, values []string) error {
+	for _, name := range names {
+		if _, ok := d.index[name]; !ok {
+			d.index[name] = len(d.header)
+			d.header = append(d.header, name)
+		}
+	}
+
+	if d.file == nil && len(d.records) < maxBufferedRecords {
+		d.records = append(d.records, discoveredRecord{
+			names:  names,
+			values: values,
+		})
+		return nil
+	}
+
+	if d.file == nil {
+		if err := d.spill(); err != nil {
+			return err
+		}
+	}
+	return d.write(names, values)
+}
+
+// spill moves the buffered records into a temporary file.
+func (d *headerDiscovery) spill() error {
+	f, err := os.CreateTemp("", "headercsv-")
+	if err != nil {
+		return err
+	}
+	d.file = f
+	d.w = bufio.NewWriter(f)
+	for _, r := range d.records {
+		if err := d.write(r.names, r.values); err != nil {
+			return err
+		}
+	}
+	d.records = nil
+	return nil
+}
+
+func (d *headerDiscovery) write(names, values []string) error {
+	if err := d.writeUvarint(uint64(len(names))); err != nil {
+		return err
+	}
+	for _, s := range names {
+		if err := d.writeString(s); err != nil {
+			return err
+		}
+	}
+	for _, s := range values {
+		if err := d.writeString(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *headerDiscovery) writeUvarint(n uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	_, err := d.w.Write(buf[:binary.PutUvarint(buf[:], n)])
+	return err
+}
+
+func (d *headerDiscovery) writeString(s string) error {
+	if err := d.writeUvarint(uint64(len(s))); err != nil {
+		return err
+	}
+	_, err := d.w.WriteString(s)
+	return err
+}
+
+// each calls fn for each buffered record.
+func (d *headerDiscovery) each(fn func(names, values []string) error) error {
+	if d.file == nil {
+		for _, r := range d.records {
+			if err := fn(r.names, r.values); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := d.w.Flush(); err != nil {
+		return err
+	}
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(d.file)
+	for {
+		n, err := binary.ReadUvarint(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		record := make([]string, 2*n)
+		for i := range record {
+			if record[i], err = readString(r); err != nil {
+				return err
+			}
+		}
+		if err := fn(record[:n], record[n:]); err != nil {
+			return err
+		}
+	}
+}
+
+// readString reads a string written by headerDiscovery.writeString.
+func readString(r *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", noEOF(err)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", noEOF(err)
+	}
+	return string(buf), nil
+}
+
+// noEOF converts io.EOF into io.ErrUnexpectedEOF; the file ends in the middle of a record.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// close removes the temporary file.
+func (d *headerDiscovery) close() error {
+	if d.file == nil {
+		return nil
+	}
+	name := d.file.Name()
+	err := d.file.Close()
+	if err := os.Remove(name); err != nil {
+		return err
+	}
+	return err
+}
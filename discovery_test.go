@@ -0,0 +1,179 @@
+package headercsv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncodeAll_sortedMapHeader(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if err := enc.EncodeAll([]map[string]int{{"c": 1, "b": 2, "a": 3}}); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		if err := enc.Error(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != "a,b,c\n3,2,1\n" {
+			t.Errorf("got %q, want %q", buf.String(), "a,b,c\n3,2,1\n")
+		}
+	}
+}
+
+func TestEncodeAll_discoverHeader(t *testing.T) {
+	type record struct {
+		B string `csv:"b"`
+		A string `csv:"a"`
+	}
+
+	testcases := []struct {
+		name     string
+		discover int
+		in       any
+		out      string
+	}{
+		{
+			name:     "all records",
+			discover: -1,
+			in:       []map[string]string{{"a": "1"}, {"b": "2"}, {"c": "3", "a": "4"}},
+			out:      "a,b,c\n1,,\n,2,\n4,,3\n",
+		},
+		{
+			name:     "first two records",
+			discover: 2,
+			in:       []map[string]string{{"a": "1"}, {"b": "2"}, {"c": "3", "a": "4"}},
+			out:      "a,b\n1,\n,2\n4,\n",
+		},
+		{
+			name:     "more than the records",
+			discover: 10,
+			in:       []map[string]string{{"a": "1"}, {"b": "2"}},
+			out:      "a,b\n1,\n,2\n",
+		},
+		{
+			name:     "struct and map",
+			discover: -1,
+			in: []any{
+				record{B: "1", A: "2"},
+				map[string]string{"c": "3"},
+			},
+			out: "b,a,c\n1,2,\n,,3\n",
+		},
+		{
+			name:     "no records",
+			discover: -1,
+			in:       []map[string]string{},
+			out:      "",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			enc.DiscoverHeader = tc.discover
+			if err := enc.EncodeAll(tc.in); err != nil {
+				t.Fatal(err)
+			}
+			enc.Flush()
+			if err := enc.Error(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tc.out {
+				t.Errorf("got %q, want %q", buf.String(), tc.out)
+			}
+		})
+	}
+}
+
+func TestEncodeAll_discoverHeaderSpill(t *testing.T) {
+	orig := maxBufferedRecords
+	maxBufferedRecords = 2
+	defer func() { maxBufferedRecords = orig }()
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.DiscoverHeader = -1
+	in := []map[string]string{
+		{"a": "1"},
+		{"b": "2"},
+		{"c": "3,\"4\""},
+		{"d": "5\n6"},
+		{"a": "7", "e": "8"},
+	}
+	if err := enc.EncodeAll(in); err != nil {
+		t.Fatal(err)
+	}
+	if enc.discovery == nil || enc.discovery.file == nil {
+		t.Fatal("want records spilled to a temporary file")
+	}
+	enc.Flush()
+	if err := enc.Error(); err != nil {
+		t.Fatal(err)
+	}
+	want := "a,b,c,d,e\n1,,,,\n,2,,,\n,,\"3,\"\"4\"\"\",,\n,,,\"5\n6\",\n7,,,,8\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEncodeAll_discoverHeaderSpillExact(t *testing.T) {
+	in := []map[string]string{
+		{"a": "x\r\ny"},
+		{},
+		{"b": ""},
+		{"a": "\"", "b": "\r"},
+	}
+	encode := func() string {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.DiscoverHeader = -1
+		if err := enc.EncodeAll(in); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		if err := enc.Error(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	want := encode()
+	if !strings.Contains(want, "x\r\ny") {
+		t.Errorf("the value is changed: %q", want)
+	}
+
+	// the output doesn't depend on whether the records are spilled.
+	orig := maxBufferedRecords
+	maxBufferedRecords = 1
+	defer func() { maxBufferedRecords = orig }()
+	if got := encode(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncoder_SetHeaderWhileDiscovering(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.DiscoverHeader = -1
+	if err := enc.EncodeAll([]map[string]string{{"a": "1", "b": "2"}, {"c": "3"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.SetHeader([]string{"c", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.EncodeRecord(map[string]string{"a": "4", "c": "5"}); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if err := enc.Error(); err != nil {
+		t.Fatal(err)
+	}
+	want := "c,a\n,1\n3,\n5,4\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
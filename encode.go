@@ -1,6 +1,8 @@
 package headercsv
 
 import (
+	"context"
+	"database/sql/driver"
 	"encoding"
 	"encoding/csv"
 	"encoding/json"
@@ -8,16 +10,81 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
 	"strconv"
 	"sync"
+	"time"
+	"unicode/utf8"
 )
 
+// An EncodeError is returned for encoding errors.
+// Record numbers are 1-indexed.
+type EncodeError struct {
+	Record int          // Index of the record where the error occurred, excluding the header; 0 for the header
+	Field  string       // Field name where the error occurred, or empty if the error is not about a field
+	Type   reflect.Type // Go type of the value that was being encoded
+	Err    error        // The actual error
+}
+
+func (e *EncodeError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("headercsv: encode error on record %d: %v", e.Record, e.Err)
+	}
+	return fmt.Sprintf("headercsv: encode error on record %d, field %q: %v", e.Record, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
 // Encoder writes CSV records to an output stream.
 type Encoder struct {
 	MarshalField func(v any) ([]byte, error)
 
-	header []string
-	w      *csv.Writer
+	// DiscoverHeader is the number of records inspected to decide the header.
+	// If it is zero, the header is decided by the first record.
+	// If it is positive, the encoder buffers the first DiscoverHeader records
+	// and the header is the union of their fields in the order of appearance.
+	// If it is negative, all records are inspected and nothing is written until Flush is called;
+	// the buffered records are spilled to a temporary file if there are many.
+	// Flush also ends the discovery. The fields that are not in the header are dropped.
+	// It doesn't apply to the slice records, e.g. []string, whose first record is the header.
+	DiscoverHeader int
+
+	// ExcelSepDirective makes the encoder created by NewEncoderExcel write the "sep=" directive
+	// at the beginning of the output, so that Excel detects the delimiter regardless of the locale.
+	// The byte order mark is not written with the directive,
+	// because Excel doesn't recognize the directive after the byte order mark.
+	ExcelSepDirective bool
+
+	// Sanitize is the policy to protect against CSV injection, also known as formula injection.
+	// It is called for the strings and the outputs of encoding.TextMarshaler that start with
+	// '=', '+', '-', '@', tab or carriage return, and its result is written instead.
+	// SanitizePrefix and SanitizeReject are available, or any custom function can be used.
+	// The fields tagged with the "nosanitize" option are not sanitized.
+	// The header names taken from the keys of maps and JSON objects and the column names of EncodeRows
+	// are sanitized too, but the names set by SetHeader, struct tags and the header rows of slices are not.
+	// If it is nil, the fields are written verbatim.
+	Sanitize func(field string) (string, error)
+
+	header     []string
+	columns    *projection
+	w          recordWriter
+	records    int // the number of records encoded, excluding the header
+	discovery  *headerDiscovery
+	err        error
+	sliceIndex []int // the indexes of the projected columns in the slice records
+	sliceWidth int   // the number of the fields of the slice records before projection
+	excel      bool  // Excel-compatibility mode
+	noHeader   bool  // the header is not written
+}
+
+// recordWriter writes records; it is implemented by *csv.Writer.
+type recordWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
 }
 
 // NewEncoder returns a new encoder that writes to w.
@@ -54,6 +121,16 @@ func (enc *Encoder) Encode(v any) error {
 
 // EncodeRecord writes a CSV record to the stream.
 func (enc *Encoder) EncodeRecord(v any) error {
+	return enc.EncodeRecordContext(context.Background(), v)
+}
+
+// EncodeRecordContext is like EncodeRecord, but it returns a *CanceledError
+// without writing any record if ctx is done.
+func (enc *Encoder) EncodeRecordContext(ctx context.Context, v any) error {
+	if err := enc.checkContext(ctx); err != nil {
+		return err
+	}
+
 	if enc.MarshalField == nil {
 		enc.MarshalField = json.Marshal
 	}
@@ -64,7 +141,14 @@ func (enc *Encoder) EncodeRecord(v any) error {
 
 // EncodeAll writes all CSV records to the stream.
 // v must be a slice or an array.
+// If v is empty, the header is derived from the element type if possible.
 func (enc *Encoder) EncodeAll(v any) error {
+	return enc.EncodeAllContext(context.Background(), v)
+}
+
+// EncodeAllContext is like EncodeAll, but it stops encoding when ctx is done.
+// The returned error is a *CanceledError that wraps ctx.Err().
+func (enc *Encoder) EncodeAllContext(ctx context.Context, v any) error {
 	if enc.MarshalField == nil {
 		enc.MarshalField = json.Marshal
 	}
@@ -75,7 +159,17 @@ func (enc *Encoder) EncodeAll(v any) error {
 		return errors.New("headercsv: v is neither a slice nor an array")
 	}
 
+	if rv.Len() == 0 && enc.header == nil {
+		if recordType(rv.Type().Elem()).TypeHeaderNames() != nil {
+			return enc.SetHeaderFromType(rv.Type().Elem())
+		}
+		return nil
+	}
+
 	for i := 0; i < rv.Len(); i++ {
+		if err := enc.checkContext(ctx); err != nil {
+			return err
+		}
 		err := enc.encodeRecord(rv.Index(i))
 		if err != nil {
 			return err
@@ -89,32 +183,174 @@ func (enc *Encoder) encodeRecord(v reflect.Value) error {
 		v = v.Elem()
 	}
 	rt := recordType(v.Type())
+	if isSliceRecord(v.Type()) {
+		return enc.encodeSliceRecord(v)
+	}
+	if err := recordTypeError(rt); err != nil {
+		return err
+	}
 	if enc.header == nil {
 		// guess header
 		header := rt.HeaderNames(v)
 		if header == nil {
-			return errors.New("cannot decide header")
+			return &EncodeError{
+				Record: enc.records + 1,
+				Type:   v.Type(),
+				Err:    errors.New("cannot decide header"),
+			}
+		}
+		if enc.DiscoverHeader != 0 || enc.discovery != nil {
+			return enc.discover(rt, v, header)
 		}
-		if err := enc.SetHeader(header); err != nil {
+		header, err := enc.columns.apply(header, rt.TypeHeaderNames() != nil)
+		if err != nil {
+			return &EncodeError{
+				Record: enc.records + 1,
+				Type:   v.Type(),
+				Err:    err,
+			}
+		}
+		// the names of maps and JSON objects come from the data; they are sanitized.
+		if err := enc.setHeader(header, rt.TypeHeaderNames() == nil); err != nil {
 			return err
 		}
 	}
 
-	// fill record
-	record := make([]string, len(enc.header))
-	for i, k := range enc.header {
+	var record []string
+	var err error
+	if m, ok := enc.recordMarshaler(v); ok {
+		record, err = enc.marshalRecord(m, v)
+	} else {
+		record, err = enc.encodeFields(rt, v, enc.header)
+	}
+	if err != nil {
+		return err
+	}
+	if err := enc.w.Write(record); err != nil {
+		return err
+	}
+	enc.records++
+	return nil
+}
+
+// encodeSliceRecord writes the slice or array v.
+// If the header is not set yet, v is used as the header, and the columns are projected by their names.
+func (enc *Encoder) encodeSliceRecord(v reflect.Value) error {
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if enc.header == nil && enc.discovery == nil {
+		return enc.setSliceHeader(v)
+	}
+	width := len(enc.header)
+	if enc.sliceIndex != nil {
+		width = enc.sliceWidth
+	}
+	if v.Len() != width {
+		return &EncodeError{
+			Record: enc.records + 1,
+			Type:   v.Type(),
+			Err:    fmt.Errorf("%w: the record has %d fields, but the header has %d fields", csv.ErrFieldCount, v.Len(), width),
+		}
+	}
+
+	rt := &sliceRecordType{index: enc.sliceIndex, unwrap: true}
+	record, err := enc.encodeFields(rt, v, enc.header)
+	if err != nil {
+		return err
+	}
+	if err := enc.w.Write(record); err != nil {
+		return err
+	}
+	enc.records++
+	return nil
+}
+
+// setSliceHeader sets the header from the slice or array v.
+// The names are written verbatim, without Sanitize and the Excel-compatibility mode.
+func (enc *Encoder) setSliceHeader(v reflect.Value) error {
+	rt := &sliceRecordType{unwrap: true}
+	raw := &field{noSanitize: true}
+	names := make([]string, v.Len())
+	for i := range names {
+		f, _ := rt.Field(v, i, "")
+		s, err := enc.encodeField(f, raw)
+		if err != nil {
+			return &EncodeError{
+				Record: enc.records + 1,
+				Type:   f.Type(),
+				Err:    err,
+			}
+		}
+		names[i] = s
+	}
+
+	header, err := enc.columns.apply(names, true)
+	if err != nil {
+		return &EncodeError{
+			Record: enc.records + 1,
+			Type:   v.Type(),
+			Err:    err,
+		}
+	}
+	if enc.columns != nil {
+		index := make(map[string]int, len(names))
+		for i := len(names) - 1; i >= 0; i-- {
+			index[names[i]] = i
+		}
+		enc.sliceIndex = make([]int, len(header))
+		for i, name := range header {
+			enc.sliceIndex[i] = index[name]
+		}
+		enc.sliceWidth = len(names)
+	}
+	return enc.SetHeader(header)
+}
+
+// isSliceRecord reports whether t is a slice or an array, or a pointer to them.
+func isSliceRecord(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+}
+
+// encodeFields encodes the fields of v in the order of header.
+func (enc *Encoder) encodeFields(rt recordInterface, v reflect.Value, header []string) ([]string, error) {
+	record := make([]string, len(header))
+	for i, k := range header {
 		v, opt := rt.Field(v, i, k)
+		if !v.IsValid() {
+			// v is a map that doesn't have the key.
+			record[i] = ""
+			continue
+		}
 		if opt != nil && opt.omitEmpty && isEmptyValue(v) {
 			record[i] = ""
 			continue
 		}
 		s, err := enc.encodeField(v, opt)
 		if err != nil {
-			return err
+			return nil, &EncodeError{
+				Record: enc.records + 1,
+				Field:  k,
+				Type:   v.Type(),
+				Err:    err,
+			}
+		}
+		if enc.excel {
+			s = quoteExcelText(s)
 		}
 		record[i] = s
 	}
-	return enc.w.Write(record)
+	return record, nil
+}
+
+func (enc *Encoder) checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return &CanceledError{Records: enc.records, Err: err}
+	}
+	return nil
 }
 
 // steel from https://github.com/golang/go/blob/1763ee199d33d2592332a29cfc3da7811718a4fd/src/encoding/json/encode.go#L318-L330
@@ -132,18 +368,55 @@ func isEmptyValue(v reflect.Value) bool {
 	return false
 }
 
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
 func (enc *Encoder) encodeField(v reflect.Value, opt *field) (string, error) {
+	if v.Kind() == reflect.Interface && !v.IsNil() && v.Elem().Kind() == reflect.Pointer && v.Elem().IsNil() {
+		// the dynamic value is a nil pointer; check the methods of its type.
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		// don't call the methods with nil receivers.
+		if v.Type().Implements(valuerType) {
+			// NULL
+			return "", nil
+		}
+		return "null", nil
+	}
+	if opt != nil && opt.layout != "" {
+		tv := v
+		for tv.Kind() == reflect.Pointer && !tv.IsNil() {
+			tv = tv.Elem()
+		}
+		if t, ok := tv.Interface().(time.Time); ok {
+			return t.Format(opt.layout), nil
+		}
+	}
 	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
 		text, err := m.MarshalText()
 		if err != nil {
 			return "", err
 		}
-		return string(text), nil
+		return enc.sanitize(string(text), opt)
+	}
+	if vr, ok := v.Interface().(driver.Valuer); ok {
+		value, err := vr.Value()
+		if err != nil {
+			return "", err
+		}
+		switch value := value.(type) {
+		case nil:
+			// NULL
+			return "", nil
+		case []byte:
+			return enc.sanitize(string(value), opt)
+		}
+		return enc.encodeField(reflect.ValueOf(value), opt)
 	}
 
 	switch v.Kind() {
 	case reflect.String:
-		return v.String(), nil
+		return enc.sanitize(v.String(), opt)
 	case reflect.Bool:
 		return strconv.FormatBool(v.Bool()), nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -171,28 +444,93 @@ func (enc *Encoder) encodeField(v reflect.Value, opt *field) (string, error) {
 }
 
 // SetHeader sets the header.
+// If the header is being discovered, the discovery ends and the buffered records are written with the header.
 func (enc *Encoder) SetHeader(header []string) error {
+	return enc.setHeader(header, false)
+}
+
+// setHeader sets the header.
+// If sanitize is true, the names are sanitized when the header is written,
+// but the header keeps the original names to look up the fields.
+func (enc *Encoder) setHeader(header []string, sanitize bool) error {
 	if enc.header != nil {
 		return errors.New("headercsv: the header has been already set")
 	}
+	names := header
+	if sanitize && enc.Sanitize != nil {
+		names = make([]string, len(header))
+		for i, name := range header {
+			s, err := enc.sanitize(name, nil)
+			if err != nil {
+				return &EncodeError{Field: name, Err: err}
+			}
+			names[i] = s
+		}
+	}
 	enc.header = header
-	return enc.w.Write(header)
+	if !enc.noHeader {
+		if err := enc.w.Write(names); err != nil {
+			return err
+		}
+	}
+	if enc.discovery != nil {
+		return enc.writeDiscovered()
+	}
+	return nil
+}
+
+// SetHeaderFromType sets the header derived from the record type t.
+// t must be a struct type or a pointer to a struct type.
+// The columns are in the declaration order of the fields, except that the fields
+// with the order option, e.g. `csv:"id,order=-1"`, come first in ascending order of it.
+func (enc *Encoder) SetHeaderFromType(t reflect.Type) error {
+	rt := recordType(t)
+	if err := recordTypeError(rt); err != nil {
+		return err
+	}
+	header := rt.TypeHeaderNames()
+	if header == nil {
+		return fmt.Errorf("headercsv: cannot decide header from type %s", t.String())
+	}
+	header, err := enc.columns.apply(header, true)
+	if err != nil {
+		return err
+	}
+	return enc.SetHeader(header)
+}
+
+// WriteHeader writes the header derived from the record type T.
+// It is useful to write a header-only CSV when there is no record.
+func WriteHeader[T any](enc *Encoder) error {
+	return enc.SetHeaderFromType(reflect.TypeOf((*T)(nil)).Elem())
 }
 
 // Flush writes any buffered data to the underlying io.Writer.
+// If the header is being discovered, Flush ends the discovery.
 // To check if an error occurred during the Flush, call Error.
 func (enc *Encoder) Flush() {
+	if enc.discovery != nil {
+		if err := enc.endDiscovery(); err != nil && enc.err == nil {
+			enc.err = err
+		}
+	}
 	enc.w.Flush()
 }
 
 // Error reports any error that has occurred during a previous Write or Flush.
 func (enc *Encoder) Error() error {
+	if enc.err != nil {
+		return enc.err
+	}
 	return enc.w.Error()
 }
 
 type recordInterface interface {
 	Field(v reflect.Value, i int, name string) (reflect.Value, *field)
 	HeaderNames(v reflect.Value) []string
+
+	// TypeHeaderNames returns the header decided by the type, or nil if it depends on the value.
+	TypeHeaderNames() []string
 }
 
 var recordTypeCache sync.Map
@@ -209,6 +547,12 @@ func recordType(t reflect.Type) recordInterface {
 }
 
 func newRecordType(t reflect.Type) recordInterface {
+	switch t {
+	case jsonRecordType:
+		return &jsonRecordInterface{}
+	case sqlRecordType:
+		return &sqlRecordInterface{}
+	}
 	switch t.Kind() {
 	case reflect.Map:
 		return newMapRecordType(t)
@@ -219,7 +563,7 @@ func newRecordType(t reflect.Type) recordInterface {
 	case reflect.Slice, reflect.Array:
 		return newSliceRecordType(t)
 	}
-	return nil
+	return &unsupportedRecordType{}
 }
 
 type unsupportedRecordType struct{}
@@ -232,6 +576,10 @@ func (rt *unsupportedRecordType) HeaderNames(v reflect.Value) []string {
 	return nil
 }
 
+func (rt *unsupportedRecordType) TypeHeaderNames() []string {
+	return nil
+}
+
 type mapRecordType struct {
 }
 
@@ -245,9 +593,14 @@ func (rt *mapRecordType) HeaderNames(v reflect.Value) []string {
 	for i, k := range vkeys {
 		keys[i] = k.String()
 	}
+	sort.Strings(keys)
 	return keys
 }
 
+func (rt *mapRecordType) TypeHeaderNames() []string {
+	return nil
+}
+
 func newMapRecordType(t reflect.Type) recordInterface {
 	if t.Key().Kind() != reflect.String {
 		return &unsupportedRecordType{}
@@ -256,13 +609,26 @@ func newMapRecordType(t reflect.Type) recordInterface {
 }
 
 type field struct {
-	index     int
-	omitEmpty bool
+	index      int
+	omitEmpty  bool
+	noSanitize bool
+	order      int
+	ordered    bool   // the field has the order option
+	layout     string // the layout of time.Time
+
+	// the layout in the fixed-width format
+	width int
+	align Align
+	pad   rune
+
+	validation *validation // nil if the field has no validation option
 }
 
 type structRecordType struct {
-	headers []string
-	fields  map[string]*field
+	headers   []string
+	fields    map[string]*field
+	validated bool  // some fields have validation options
+	err       error // the error in the tags, reported by recordTypeError
 }
 
 func (rt *structRecordType) Field(v reflect.Value, i int, name string) (reflect.Value, *field) {
@@ -277,10 +643,16 @@ func (rt *structRecordType) HeaderNames(v reflect.Value) []string {
 	return rt.headers
 }
 
+func (rt *structRecordType) TypeHeaderNames() []string {
+	return rt.headers
+}
+
 func newStructRecordType(t reflect.Type) recordInterface {
 	num := t.NumField()
 	headers := make([]string, 0, num)
 	fields := make(map[string]*field, num)
+	validated := false
+	var typeErr error
 	for i := 0; i < num; i++ {
 		tag := t.Field(i).Tag.Get("csv")
 		if tag == "-" {
@@ -290,15 +662,65 @@ func newStructRecordType(t reflect.Type) recordInterface {
 		if name == "" {
 			name = t.Field(i).Name
 		}
-		headers = append(headers, name)
-		fields[name] = &field{
-			index:     i,
-			omitEmpty: opts.Contains("omitempty"),
+		opts, v := parseValidation(t.Field(i).Type, opts)
+		f := &field{
+			index:      i,
+			omitEmpty:  opts.Contains("omitempty"),
+			noSanitize: opts.Contains("nosanitize"),
+			validation: v,
+		}
+		validated = validated || v != nil
+		if order, ok := opts.Get("order"); ok {
+			n, err := strconv.Atoi(order)
+			if err != nil && typeErr == nil {
+				typeErr = fmt.Errorf("headercsv: invalid order option of field %s in %s: %q", t.Field(i).Name, t, order)
+			}
+			f.order, f.ordered = n, true
+		}
+		if layout, ok := opts.Get("layout"); ok {
+			f.layout = layout
 		}
+		if width, ok := opts.Get("width"); ok {
+			if n, err := strconv.Atoi(width); err == nil {
+				f.width = n
+			}
+		}
+		if align, ok := opts.Get("align"); ok && align == "right" {
+			f.align = AlignRight
+		}
+		if pad, ok := opts.Get("pad"); ok && pad != "" {
+			f.pad, _ = utf8.DecodeRuneInString(pad)
+		}
+		headers = append(headers, name)
+		fields[name] = f
 	}
+	// the fields with the order option come first, and the others keep the declaration order.
+	sort.SliceStable(headers, func(i, j int) bool {
+		fi, fj := fields[headers[i]], fields[headers[j]]
+		if fi.ordered != fj.ordered {
+			return fi.ordered
+		}
+		return fi.order < fj.order
+	})
 	return &structRecordType{
-		headers: headers,
-		fields:  fields,
+		headers:   headers,
+		fields:    fields,
+		validated: validated,
+		err:       typeErr,
+	}
+}
+
+// recordTypeError returns the error in the tags of the struct type of rt, if any.
+func recordTypeError(rt recordInterface) error {
+	for {
+		switch r := rt.(type) {
+		case *ptrRecordType:
+			rt = r.elem
+		case *structRecordType:
+			return r.err
+		default:
+			return nil
+		}
 	}
 }
 
@@ -314,6 +736,10 @@ func (rt *ptrRecordType) HeaderNames(v reflect.Value) []string {
 	return rt.elem.HeaderNames(v.Elem())
 }
 
+func (rt *ptrRecordType) TypeHeaderNames() []string {
+	return rt.elem.TypeHeaderNames()
+}
+
 func newPtrRecordType(t reflect.Type) recordInterface {
 	elem := recordType(t.Elem())
 	return &ptrRecordType{
@@ -322,19 +748,32 @@ func newPtrRecordType(t reflect.Type) recordInterface {
 }
 
 type sliceRecordType struct {
+	index  []int // the indexes of the elements of the projected columns, or nil if not projected
+	unwrap bool  // encode the interface elements, e.g. of []any rows, by their dynamic types
 }
 
 func (rt *sliceRecordType) Field(v reflect.Value, i int, name string) (reflect.Value, *field) {
+	if rt.index != nil {
+		i = rt.index[i]
+	}
 	if i >= v.Len() {
 		return reflect.Zero(v.Type().Elem()), nil
 	}
-	return v.Index(i), nil
+	elem := v.Index(i)
+	if rt.unwrap && elem.Kind() == reflect.Interface && !elem.IsNil() {
+		elem = elem.Elem()
+	}
+	return elem, nil
 }
 
 func (rt *sliceRecordType) HeaderNames(v reflect.Value) []string {
 	return nil
 }
 
+func (rt *sliceRecordType) TypeHeaderNames() []string {
+	return nil
+}
+
 func newSliceRecordType(t reflect.Type) recordInterface {
 	return &sliceRecordType{}
 }
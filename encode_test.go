@@ -2,6 +2,9 @@ package headercsv
 
 import (
 	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
 	"reflect"
 	"sort"
 	"testing"
@@ -373,3 +376,392 @@ func TestNamedRecordType(t *testing.T) {
 		}
 	}
 }
+
+// cancelMarshal calls cancel when it is encoded.
+type cancelMarshal struct {
+	cancel context.CancelFunc
+}
+
+func (c cancelMarshal) MarshalText() ([]byte, error) {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return []byte("ok"), nil
+}
+
+func TestEncodeAllContext(t *testing.T) {
+	t.Run("canceled before encoding", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		err := enc.EncodeAllContext(ctx, []map[string]string{{"a": "b"}})
+		var canceledErr *CanceledError
+		if !errors.As(err, &canceledErr) {
+			t.Fatalf("want CanceledError, got %v", err)
+		}
+		if canceledErr.Records != 0 {
+			t.Errorf("got %d, want 0", canceledErr.Records)
+		}
+		enc.Flush()
+		if buf.String() != "" {
+			t.Errorf("got %q, want empty", buf.String())
+		}
+	})
+
+	t.Run("canceled while encoding", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		type record struct {
+			A cancelMarshal `csv:"a"`
+		}
+		in := []record{{}, {cancelMarshal{cancel}}, {}}
+
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		err := enc.EncodeAllContext(ctx, in)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+		var canceledErr *CanceledError
+		if !errors.As(err, &canceledErr) {
+			t.Fatal("want CanceledError, but none")
+		}
+		if canceledErr.Records != 2 {
+			t.Errorf("got %d, want 2", canceledErr.Records)
+		}
+		enc.Flush()
+		if buf.String() != "a\nok\nok\n" {
+			t.Errorf("got %q, want %q", buf.String(), "a\nok\nok\n")
+		}
+	})
+}
+
+func TestEncodeRecordContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.EncodeRecordContext(ctx, map[string]string{"a": "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	err := enc.EncodeRecordContext(ctx, map[string]string{"a": "c"})
+	var canceledErr *CanceledError
+	if !errors.As(err, &canceledErr) {
+		t.Fatalf("want CanceledError, got %v", err)
+	}
+	if canceledErr.Records != 1 {
+		t.Errorf("got %d, want 1", canceledErr.Records)
+	}
+	enc.Flush()
+	if buf.String() != "a\nb\n" {
+		t.Errorf("got %q, want %q", buf.String(), "a\nb\n")
+	}
+}
+
+type errorMarshal struct{}
+
+var errTestMarshal = errors.New("marshal error")
+
+func (errorMarshal) MarshalText() ([]byte, error) {
+	return nil, errTestMarshal
+}
+
+func TestEncodeError(t *testing.T) {
+	t.Run("MarshalText fails", func(t *testing.T) {
+		type record struct {
+			A string       `csv:"a"`
+			B errorMarshal `csv:"b"`
+		}
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		err := enc.EncodeAll([]any{
+			map[string]string{"a": "foo", "b": "bar"},
+			record{},
+		})
+		var encodeErr *EncodeError
+		if !errors.As(err, &encodeErr) {
+			t.Fatalf("want EncodeError, got %v", err)
+		}
+		if encodeErr.Record != 2 {
+			t.Errorf("got %d, want 2", encodeErr.Record)
+		}
+		if encodeErr.Field != "b" {
+			t.Errorf("got %q, want %q", encodeErr.Field, "b")
+		}
+		if encodeErr.Type != reflect.TypeOf(errorMarshal{}) {
+			t.Errorf("got %v, want errorMarshal", encodeErr.Type)
+		}
+		if !errors.Is(err, errTestMarshal) {
+			t.Errorf("want errTestMarshal, got %v", err)
+		}
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		type record struct {
+			A chan int `csv:"a"`
+		}
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		err := enc.EncodeRecord(record{})
+		var encodeErr *EncodeError
+		if !errors.As(err, &encodeErr) {
+			t.Fatalf("want EncodeError, got %v", err)
+		}
+		if encodeErr.Record != 1 {
+			t.Errorf("got %d, want 1", encodeErr.Record)
+		}
+		if encodeErr.Field != "a" {
+			t.Errorf("got %q, want %q", encodeErr.Field, "a")
+		}
+		if encodeErr.Type != reflect.TypeOf(make(chan int)) {
+			t.Errorf("got %v, want chan int", encodeErr.Type)
+		}
+	})
+
+	t.Run("cannot decide header", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		err := enc.EncodeRecord(map[int]string{1: "a"})
+		var encodeErr *EncodeError
+		if !errors.As(err, &encodeErr) {
+			t.Fatalf("want EncodeError, got %v", err)
+		}
+		if encodeErr.Record != 1 {
+			t.Errorf("got %d, want 1", encodeErr.Record)
+		}
+		if encodeErr.Field != "" {
+			t.Errorf("got %q, want empty", encodeErr.Field)
+		}
+		if encodeErr.Type != reflect.TypeOf(map[int]string{}) {
+			t.Errorf("got %v, want map[int]string", encodeErr.Type)
+		}
+	})
+}
+
+func TestEncodeAll_empty(t *testing.T) {
+	type record struct {
+		A string `csv:"a"`
+		B int    `csv:"b"`
+	}
+
+	testcases := []struct {
+		in  any
+		out string
+	}{
+		{[]record{}, "a,b\n"},
+		{[]*record(nil), "a,b\n"},
+		{[0]record{}, "a,b\n"},
+		{[]map[string]string{}, ""},
+		{[]any{}, ""},
+	}
+
+	for _, tc := range testcases {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if err := enc.EncodeAll(tc.in); err != nil {
+			t.Errorf("%T: unexpected error: %v", tc.in, err)
+		}
+		enc.Flush()
+		if err := enc.Error(); err != nil {
+			t.Errorf("%T: unexpected error: %v", tc.in, err)
+		}
+		if buf.String() != tc.out {
+			t.Errorf("%T: got %q, want %q", tc.in, buf.String(), tc.out)
+		}
+	}
+}
+
+func TestSetHeaderFromType(t *testing.T) {
+	type record struct {
+		A string `csv:"a"`
+		B int    `csv:"-"`
+		C int
+	}
+
+	t.Run("struct", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if err := WriteHeader[*record](enc); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		if buf.String() != "a,C\n" {
+			t.Errorf("got %q, want %q", buf.String(), "a,C\n")
+		}
+	})
+
+	t.Run("map", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if err := enc.SetHeaderFromType(reflect.TypeOf(map[string]string{})); err == nil {
+			t.Error("want error, but none")
+		}
+	})
+}
+
+func TestEncodeAll_slices(t *testing.T) {
+	testcases := []struct {
+		in  any
+		out string
+	}{
+		{
+			[][]string{{"a", "b"}, {"1", "2"}, {"3", "4"}},
+			"a,b\n1,2\n3,4\n",
+		},
+		{
+			[][2]string{{"a", "b"}, {"1", "2"}},
+			"a,b\n1,2\n",
+		},
+		{
+			[][]any{{"name", "age", "score"}, {"alice", 20, 1.5}, {"bob", nil, testMarshal(0)}},
+			"name,age,score\nalice,20,1.5\nbob,null,<testMarshal>\n",
+		},
+		{
+			// the values of interface types are encoded by MarshalField, except the elements of slice rows.
+			[]map[string]any{{"a": "b", "c": 1, "d": nil}},
+			"a,c,d\n\"\"\"b\"\"\",1,null\n",
+		},
+		{
+			[]struct {
+				A any `csv:"a"`
+			}{{"foo"}},
+			"a\n\"\"\"foo\"\"\"\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if err := enc.EncodeAll(tc.in); err != nil {
+			t.Errorf("%T: unexpected error: %v", tc.in, err)
+		}
+		enc.Flush()
+		if err := enc.Error(); err != nil {
+			t.Errorf("%T: unexpected error: %v", tc.in, err)
+		}
+		if buf.String() != tc.out {
+			t.Errorf("%T: got %q, want %q", tc.in, buf.String(), tc.out)
+		}
+	}
+}
+
+func TestEncodeAll_slicesWithHeader(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.SetHeader([]string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.EncodeAll([][]string{{"1", "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if buf.String() != "a,b\n1,2\n" {
+		t.Errorf("got %q, want %q", buf.String(), "a,b\n1,2\n")
+	}
+}
+
+func TestEncodeAll_slicesHeaderVerbatim(t *testing.T) {
+	in := [][]string{{"-x", "007"}, {"-1", "007"}}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Sanitize = SanitizePrefix
+	if err := enc.EncodeAll(in); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if want := "-x,007\n'-1,007\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	enc = NewEncoderExcel(&buf)
+	if err := enc.EncodeAll(in); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if want := "\ufeff-x,007\r\n-1,\"=\"\"007\"\"\"\r\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEncodeAll_slicesProjection(t *testing.T) {
+	in := [][]string{{"a", "b", "c"}, {"1", "2", "3"}, {"4", "5", "6"}}
+	testcases := []struct {
+		name    string
+		project func(enc *Encoder) error
+		out     string
+	}{
+		{
+			name:    "select",
+			project: func(enc *Encoder) error { return enc.SelectColumns("c", "a") },
+			out:     "c,a\n3,1\n6,4\n",
+		},
+		{
+			name:    "exclude",
+			project: func(enc *Encoder) error { return enc.ExcludeColumns("b") },
+			out:     "a,c\n1,3\n4,6\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			if err := tc.project(enc); err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.EncodeAll(in); err != nil {
+				t.Fatal(err)
+			}
+			enc.Flush()
+			if buf.String() != tc.out {
+				t.Errorf("got %q, want %q", buf.String(), tc.out)
+			}
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		enc := NewEncoder(&bytes.Buffer{})
+		if err := enc.SelectColumns("d"); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.EncodeAll(in); err == nil {
+			t.Error("want error, but none")
+		}
+	})
+
+	t.Run("length", func(t *testing.T) {
+		enc := NewEncoder(&bytes.Buffer{})
+		if err := enc.SelectColumns("a"); err != nil {
+			t.Fatal(err)
+		}
+		err := enc.EncodeAll([][]string{{"a", "b"}, {"1"}})
+		if !errors.Is(err, csv.ErrFieldCount) {
+			t.Errorf("want csv.ErrFieldCount, got %v", err)
+		}
+	})
+}
+
+func TestEncodeAll_slicesLength(t *testing.T) {
+	for _, in := range [][]string{{"1"}, {"1", "2", "3"}} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		err := enc.EncodeAll([][]string{{"a", "b"}, {"1", "2"}, in})
+		var encodeErr *EncodeError
+		if !errors.As(err, &encodeErr) {
+			t.Fatalf("%v: want EncodeError, got %v", in, err)
+		}
+		if encodeErr.Record != 2 {
+			t.Errorf("%v: got %d, want 2", in, encodeErr.Record)
+		}
+		if !errors.Is(err, csv.ErrFieldCount) {
+			t.Errorf("%v: want csv.ErrFieldCount, got %v", in, err)
+		}
+	}
+}
@@ -172,3 +172,22 @@ Ed,Go fmt yourself!
 	// Sam: Go fmt who?
 	//  Ed: Go fmt yourself!
 }
+
+func ExampleWriteHeader() {
+	type Record struct {
+		Name string `csv:"name"`
+		Text string `csv:"text"`
+	}
+
+	enc := headercsv.NewEncoder(os.Stdout)
+	if err := headercsv.WriteHeader[Record](enc); err != nil {
+		panic(err)
+	}
+	enc.Flush()
+	if err := enc.Error(); err != nil {
+		panic(err)
+	}
+
+	// Output:
+	// name,text
+}
@@ -0,0 +1,116 @@
+package headercsv
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// NewEncoderExcel returns a new encoder that writes to w in the Excel-compatibility mode.
+// In this mode, the encoder writes a UTF-8 byte order mark at the beginning of the output,
+// uses CRLF as the line terminator,
+// and writes numeric strings with leading zeros, such as "00123", as the formula ="00123"
+// so that Excel doesn't strip the zeros.
+func NewEncoderExcel(w io.Writer) *Encoder {
+	enc := &Encoder{excel: true}
+	ew := &excelWriter{enc: enc, w: w}
+	ew.cw = csv.NewWriter(ew)
+	ew.cw.UseCRLF = true
+	enc.w = ew.cw
+	return enc
+}
+
+// NewDecoderExcel returns a new decoder that reads from r in the Excel-compatibility mode.
+// In this mode, the decoder understands the "sep=" directive at the beginning of the input,
+// and unquotes the fields written as the formula ="...".
+// Quotes in unquoted fields are allowed as Excel does.
+// The input is converted into UTF-8 by NewUTF8Reader.
+// The byte order mark is removed from the header in any mode.
+func NewDecoderExcel(r io.Reader) *Decoder {
+	cr := newCSVReader(r)
+	cr.LazyQuotes = true
+	return &Decoder{r: cr, excel: true}
+}
+
+// excelWriter writes the prefix of the Excel-compatibility mode before the first write.
+type excelWriter struct {
+	enc     *Encoder
+	cw      *csv.Writer
+	w       io.Writer
+	started bool
+}
+
+func (w *excelWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		prefix := "\ufeff"
+		if w.enc.ExcelSepDirective {
+			prefix = "sep=" + string(w.cw.Comma) + "\r\n"
+		}
+		if _, err := io.WriteString(w.w, prefix); err != nil {
+			return 0, err
+		}
+	}
+	return w.w.Write(p)
+}
+
+// readHeader reads the header record.
+// In the Excel-compatibility mode, it handles the "sep=" directive.
+func (dec *Decoder) readHeader() ([]string, error) {
+	cr, ok := dec.r.(*csv.Reader)
+	if !dec.excel || !ok {
+		return dec.r.Read()
+	}
+
+	// the directive must not fix the number of fields.
+	fields := cr.FieldsPerRecord
+	record, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	comma, ok := parseSepDirective(record, cr.Comma)
+	if !ok {
+		return record, nil
+	}
+	cr.Comma = comma
+	cr.FieldsPerRecord = fields
+	return cr.Read()
+}
+
+// parseSepDirective parses the "sep=" directive of Excel.
+func parseSepDirective(record []string, comma rune) (rune, bool) {
+	line := strings.Join(record, string(comma))
+	line = strings.TrimPrefix(line, "\ufeff")
+	if !strings.HasPrefix(line, "sep=") {
+		return 0, false
+	}
+	sep := line[len("sep="):]
+	r, size := utf8.DecodeRuneInString(sep)
+	if r == utf8.RuneError || size != len(sep) {
+		return 0, false
+	}
+	return r, true
+}
+
+// quoteExcelText quotes s as the formula ="..." if Excel would strip leading zeros of s.
+func quoteExcelText(s string) string {
+	if len(s) < 2 || s[0] != '0' {
+		return s
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return s
+		}
+	}
+	return `="` + s + `"`
+}
+
+// unquoteExcelText unquotes the fields written as the formula ="...".
+func unquoteExcelText(record []string) {
+	for i, s := range record {
+		if len(s) >= 3 && strings.HasPrefix(s, `="`) && strings.HasSuffix(s, `"`) {
+			record[i] = strings.ReplaceAll(s[2:len(s)-1], `""`, `"`)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package headercsv
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type excelRecord struct {
+	Code string `csv:"code"`
+	Name string `csv:"name"`
+	Qty  int    `csv:"qty"`
+}
+
+func TestEncoderExcel(t *testing.T) {
+	in := []excelRecord{
+		{"00123", "apple", 1},
+		{"0", "orange", 2},
+		{"0x1", "banana", 3},
+	}
+
+	t.Run("default", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoderExcel(&buf)
+		if err := enc.EncodeAll(in); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		if err := enc.Error(); err != nil {
+			t.Fatal(err)
+		}
+		want := "\ufeffcode,name,qty\r\n\"=\"\"00123\"\"\",apple,1\r\n0,orange,2\r\n0x1,banana,3\r\n"
+		if buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("sep directive", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoderExcel(&buf)
+		enc.ExcelSepDirective = true
+		if err := enc.EncodeAll(in[:1]); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		if err := enc.Error(); err != nil {
+			t.Fatal(err)
+		}
+		want := "sep=,\r\ncode,name,qty\r\n\"=\"\"00123\"\"\",apple,1\r\n"
+		if buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+}
+
+func TestDecoderExcel(t *testing.T) {
+	testcases := []struct {
+		name string
+		in   string
+	}{
+		{
+			name: "byte order mark",
+			in:   "\ufeffcode,name,qty\r\n\"=\"\"00123\"\"\",apple,1\r\n",
+		},
+		{
+			name: "sep directive",
+			in:   "sep=;\r\ncode;name;qty\r\n=\"00123\";apple;1\r\n",
+		},
+		{
+			name: "sep directive with comma",
+			in:   "sep=,\r\ncode,name,qty\r\n\"=\"\"00123\"\"\",apple,1\r\n",
+		},
+		{
+			name: "byte order mark and sep directive",
+			in:   "\ufeffsep=\t\r\ncode\tname\tqty\r\n00123\tapple\t1\r\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dec := NewDecoderExcel(bytes.NewBufferString(tc.in))
+			var v []excelRecord
+			if err := dec.DecodeAll(&v); err != nil {
+				t.Fatal(err)
+			}
+			want := []excelRecord{{"00123", "apple", 1}}
+			if !reflect.DeepEqual(v, want) {
+				t.Errorf("got %#v, want %#v", v, want)
+			}
+		})
+	}
+}
+
+func TestDecoder_byteOrderMark(t *testing.T) {
+	dec := NewDecoder(bytes.NewBufferString("\ufeffa,b\n1,2\n"))
+	var v []map[string]string
+	if err := dec.DecodeAll(&v); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{{"a": "1", "b": "2"}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %#v, want %#v", v, want)
+	}
+}
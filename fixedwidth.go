@@ -0,0 +1,407 @@
+package headercsv
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// Align is the alignment of a column in the fixed-width format.
+type Align int
+
+const (
+	// AlignLeft pads the values on the right.
+	AlignLeft Align = iota
+
+	// AlignRight pads the values on the left.
+	AlignRight
+)
+
+// FixedWidthColumn is a column of the fixed-width format.
+type FixedWidthColumn struct {
+	Name  string // the column name
+	Width int    // the width in runes
+	Align Align  // the alignment
+	Pad   rune   // the padding character; if it is zero, ' ' is used
+}
+
+func (c *FixedWidthColumn) pad() rune {
+	if c.Pad == 0 {
+		return ' '
+	}
+	return c.Pad
+}
+
+// format pads s to the width of the column.
+func (c *FixedWidthColumn) format(s string) (string, error) {
+	n := utf8.RuneCountInString(s)
+	if n > c.Width {
+		return "", fmt.Errorf("the value %q exceeds the width %d", s, c.Width)
+	}
+	padding := strings.Repeat(string(c.pad()), c.Width-n)
+	if c.Align == AlignLeft {
+		return s + padding, nil
+	}
+	if c.pad() == '0' && strings.HasPrefix(s, "-") {
+		// the sign precedes the zeros, e.g. -00012
+		return "-" + padding + s[1:], nil
+	}
+	return padding + s, nil
+}
+
+// truncate cuts s to the width of the column.
+func (c *FixedWidthColumn) truncate(s string) string {
+	n := 0
+	for i := range s {
+		if n == c.Width {
+			return s[:i]
+		}
+		n++
+	}
+	return s
+}
+
+// parse removes the padding from s.
+func (c *FixedWidthColumn) parse(s string) string {
+	pad := c.pad()
+	if c.Align == AlignLeft {
+		return strings.TrimRight(s, string(pad))
+	}
+	var sign string
+	if pad == '0' && strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	s = strings.TrimLeft(s, string(pad))
+	if s == "" && pad != ' ' {
+		// the value consists of the padding only, e.g. 0000
+		s = string(pad)
+	}
+	return sign + s
+}
+
+func validateLayout(layout []FixedWidthColumn) error {
+	if len(layout) == 0 {
+		return errors.New("headercsv: the layout is empty")
+	}
+	for _, c := range layout {
+		if c.Width <= 0 {
+			return fmt.Errorf("headercsv: the width of column %q is not specified", c.Name)
+		}
+	}
+	return nil
+}
+
+func layoutNames(layout []FixedWidthColumn) []string {
+	names := make([]string, len(layout))
+	for i, c := range layout {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// layoutFromType returns the layout that is configured by the struct tags of t.
+func layoutFromType(t reflect.Type) ([]FixedWidthColumn, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	rt, ok := recordType(t).(*structRecordType)
+	if !ok {
+		return nil, fmt.Errorf("headercsv: cannot decide the layout from type %s", t.String())
+	}
+	if rt.err != nil {
+		return nil, rt.err
+	}
+	layout := make([]FixedWidthColumn, 0, len(rt.headers))
+	for _, name := range rt.headers {
+		f := rt.fields[name]
+		layout = append(layout, FixedWidthColumn{
+			Name:  name,
+			Width: f.width,
+			Align: f.align,
+			Pad:   f.pad,
+		})
+	}
+	if err := validateLayout(layout); err != nil {
+		return nil, fmt.Errorf("%w in %s", err, t.String())
+	}
+	return layout, nil
+}
+
+// FixedWidthDecoder reads and decodes records of the fixed-width format.
+// The layout of the columns is configured by the tag options width, align and pad,
+// e.g. `csv:"amount,width=12,align=right,pad=0"`, or by SetLayout.
+// The input has no header line; the column names of the layout are used as the header.
+type FixedWidthDecoder struct {
+	*Decoder
+	r *fixedWidthReader
+}
+
+// NewFixedWidthDecoder returns a new decoder that reads from r.
+// The input is converted into UTF-8 by NewUTF8Reader.
+func NewFixedWidthDecoder(r io.Reader) *FixedWidthDecoder {
+	fr := &fixedWidthReader{r: bufio.NewReader(NewUTF8Reader(r))}
+	return &FixedWidthDecoder{
+		Decoder: &Decoder{r: fr},
+		r:       fr,
+	}
+}
+
+// SetLayout sets the layout of the columns.
+// It must be called before decoding any record.
+func (dec *FixedWidthDecoder) SetLayout(layout []FixedWidthColumn) error {
+	if dec.r.layout != nil {
+		return errors.New("headercsv: the layout has been already set")
+	}
+	if err := validateLayout(layout); err != nil {
+		return err
+	}
+	if err := dec.SetHeader(layoutNames(layout)); err != nil {
+		return err
+	}
+	dec.r.layout = layout
+	return nil
+}
+
+func (dec *FixedWidthDecoder) initLayout(t reflect.Type) error {
+	if dec.r.layout != nil {
+		return nil
+	}
+	layout, err := layoutFromType(t)
+	if err != nil {
+		return err
+	}
+	return dec.SetLayout(layout)
+}
+
+// DecodeRecord reads the next record from its input and stores it in the value pointed to by v.
+func (dec *FixedWidthDecoder) DecodeRecord(v any) error {
+	return dec.DecodeRecordContext(context.Background(), v)
+}
+
+// DecodeRecordContext is like DecodeRecord, but it returns a *CanceledError
+// without reading any record if ctx is done.
+func (dec *FixedWidthDecoder) DecodeRecordContext(ctx context.Context, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer {
+		return errors.New("headercsv: v is not a pointer")
+	}
+	if err := dec.initLayout(rv.Type().Elem()); err != nil {
+		return err
+	}
+	return dec.Decoder.DecodeRecordContext(ctx, v)
+}
+
+// DecodeAll reads all records from its input.
+// v must be a pinter to a slice or a pointer to an array.
+func (dec *FixedWidthDecoder) DecodeAll(v any) error {
+	return dec.DecodeAllContext(context.Background(), v)
+}
+
+// DecodeAllContext is like DecodeAll, but it stops decoding when ctx is done.
+func (dec *FixedWidthDecoder) DecodeAllContext(ctx context.Context, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer {
+		return errors.New("headercsv: v is not a pointer")
+	}
+	if kind := rv.Elem().Kind(); kind != reflect.Slice && kind != reflect.Array {
+		return errors.New("headercsv: v is neither a slice nor an array")
+	}
+	if err := dec.initLayout(rv.Type().Elem().Elem()); err != nil {
+		return err
+	}
+	return dec.Decoder.DecodeAllContext(ctx, v)
+}
+
+// fixedWidthReader splits lines into fields by the layout.
+type fixedWidthReader struct {
+	r       *bufio.Reader
+	layout  []FixedWidthColumn
+	line    int
+	offsets []int // the byte offsets of the fields in the current line
+}
+
+func (r *fixedWidthReader) Read() ([]string, error) {
+	if r.layout == nil {
+		return nil, errors.New("headercsv: the layout is not set")
+	}
+	for {
+		line, err := r.r.ReadString('\n')
+		if line == "" && err != nil {
+			return nil, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		r.line++
+		line = strings.TrimSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			// skip empty lines as encoding/csv does.
+			continue
+		}
+		return r.split(line), nil
+	}
+}
+
+func (r *fixedWidthReader) split(line string) []string {
+	record := make([]string, len(r.layout))
+	r.offsets = r.offsets[:0]
+	pos := 0
+	for i := range r.layout {
+		c := &r.layout[i]
+		r.offsets = append(r.offsets, pos)
+		start := pos
+		for n := 0; n < c.Width && pos < len(line); n++ {
+			_, size := utf8.DecodeRuneInString(line[pos:])
+			pos += size
+		}
+		record[i] = c.parse(line[start:pos])
+	}
+	// the characters after the last column are ignored.
+	return record
+}
+
+func (r *fixedWidthReader) FieldPos(field int) (line, column int) {
+	if field < 0 || field >= len(r.offsets) {
+		panic("out of range index passed to FieldPos")
+	}
+	return r.line, r.offsets[field] + 1
+}
+
+// FixedWidthEncoder encodes and writes records of the fixed-width format.
+// The layout of the columns is configured by the tag options width, align and pad,
+// e.g. `csv:"amount,width=12,align=right,pad=0"`, or by SetLayout.
+// The values longer than the width of the column are reported as *EncodeError.
+type FixedWidthEncoder struct {
+	*Encoder
+	w *fixedWidthWriter
+}
+
+// NewFixedWidthEncoder returns a new encoder that writes to w.
+// If header is true, the column names are written as the first line, padded with spaces
+// and truncated to the widths of the columns.
+func NewFixedWidthEncoder(w io.Writer, header bool) *FixedWidthEncoder {
+	fw := &fixedWidthWriter{w: bufio.NewWriter(w), header: header}
+	fw.enc = &Encoder{w: fw, noHeader: !header}
+	return &FixedWidthEncoder{
+		Encoder: fw.enc,
+		w:       fw,
+	}
+}
+
+// SetLayout sets the layout of the columns.
+// It must be called before encoding any record.
+func (enc *FixedWidthEncoder) SetLayout(layout []FixedWidthColumn) error {
+	if enc.w.layout != nil {
+		return errors.New("headercsv: the layout has been already set")
+	}
+	if err := validateLayout(layout); err != nil {
+		return err
+	}
+	enc.w.layout = layout
+	return enc.SetHeader(layoutNames(layout))
+}
+
+func (enc *FixedWidthEncoder) initLayout(t reflect.Type) error {
+	if enc.w.layout != nil {
+		return nil
+	}
+	layout, err := layoutFromType(t)
+	if err != nil {
+		return err
+	}
+	return enc.SetLayout(layout)
+}
+
+// EncodeRecord writes a record to the stream.
+func (enc *FixedWidthEncoder) EncodeRecord(v any) error {
+	return enc.EncodeRecordContext(context.Background(), v)
+}
+
+// EncodeRecordContext is like EncodeRecord, but it returns a *CanceledError
+// without writing any record if ctx is done.
+func (enc *FixedWidthEncoder) EncodeRecordContext(ctx context.Context, v any) error {
+	if err := enc.initLayout(reflect.TypeOf(v)); err != nil {
+		return err
+	}
+	return enc.Encoder.EncodeRecordContext(ctx, v)
+}
+
+// EncodeAll writes all records to the stream.
+// v must be a slice or an array.
+func (enc *FixedWidthEncoder) EncodeAll(v any) error {
+	return enc.EncodeAllContext(context.Background(), v)
+}
+
+// EncodeAllContext is like EncodeAll, but it stops encoding when ctx is done.
+func (enc *FixedWidthEncoder) EncodeAllContext(ctx context.Context, v any) error {
+	t := reflect.TypeOf(v)
+	if t == nil || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+		return errors.New("headercsv: v is neither a slice nor an array")
+	}
+	if err := enc.initLayout(t.Elem()); err != nil {
+		return err
+	}
+	return enc.Encoder.EncodeAllContext(ctx, v)
+}
+
+// fixedWidthWriter joins fields into lines by the layout.
+type fixedWidthWriter struct {
+	enc    *Encoder
+	w      *bufio.Writer
+	layout []FixedWidthColumn
+	header bool // the next record is the header
+	err    error
+}
+
+func (w *fixedWidthWriter) Write(record []string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(record) != len(w.layout) {
+		return fmt.Errorf("headercsv: the record has %d fields, but the layout has %d columns", len(record), len(w.layout))
+	}
+	header := w.header
+	w.header = false
+	var line strings.Builder
+	for i, s := range record {
+		c := w.layout[i]
+		if header {
+			// the column names are padded with spaces, e.g. "  amount" rather than "00amount",
+			// and truncated, e.g. "f" for `csv:"flag,width=1"`.
+			c.Pad = ' '
+			s = c.truncate(s)
+		}
+		s, err := c.format(s)
+		if err != nil {
+			return &EncodeError{
+				Record: w.enc.records + 1,
+				Field:  c.Name,
+				Err:    err,
+			}
+		}
+		line.WriteString(s)
+	}
+	line.WriteByte('\n')
+	if _, err := w.w.WriteString(line.String()); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+func (w *fixedWidthWriter) Flush() {
+	if w.err != nil {
+		return
+	}
+	w.err = w.w.Flush()
+}
+
+func (w *fixedWidthWriter) Error() error {
+	return w.err
+}
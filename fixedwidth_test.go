@@ -0,0 +1,198 @@
+package headercsv
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fixedWidthRecord struct {
+	ID     string `csv:"id,width=4"`
+	Name   string `csv:"name,width=8"`
+	Amount int    `csv:"amount,width=6,align=right,pad=0"`
+	Note   string `csv:"note,width=5,align=right"`
+}
+
+func TestFixedWidthEncoder(t *testing.T) {
+	in := []fixedWidthRecord{
+		{"A1", "apple", 1234, "x"},
+		{"B22", "orange", -12, ""},
+	}
+
+	t.Run("tags", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewFixedWidthEncoder(&buf, false)
+		if err := enc.EncodeAll(in); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		if err := enc.Error(); err != nil {
+			t.Fatal(err)
+		}
+		want := "A1  apple   001234    x\n" +
+			"B22 orange  -00012     \n"
+		if buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("header", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewFixedWidthEncoder(&buf, true)
+		if err := enc.EncodeRecord(in[0]); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		want := "id  name    amount note\n" +
+			"A1  apple   001234    x\n"
+		if buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("runtime layout", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewFixedWidthEncoder(&buf, false)
+		err := enc.SetLayout([]FixedWidthColumn{
+			{Name: "code", Width: 3, Align: AlignRight, Pad: '*'},
+			{Name: "label", Width: 4},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.EncodeRecord(map[string]string{"code": "7", "label": "ab"}); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		if buf.String() != "**7ab  \n" {
+			t.Errorf("got %q", buf.String())
+		}
+	})
+
+	t.Run("runtime layout with header", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewFixedWidthEncoder(&buf, true)
+		err := enc.SetLayout([]FixedWidthColumn{
+			{Name: "amount", Width: 8, Align: AlignRight, Pad: '0'},
+			{Name: "label", Width: 6, Pad: '_'},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.EncodeRecord(map[string]string{"amount": "12", "label": "ab"}); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		want := "  amountlabel \n" +
+			"00000012ab____\n"
+		if buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewFixedWidthEncoder(&buf, false)
+		err := enc.EncodeAll([]fixedWidthRecord{{ID: "OK"}, {ID: "TOOLONG"}})
+		if err == nil || !strings.Contains(err.Error(), "exceeds the width 4") {
+			t.Errorf("unexpected error: %v", err)
+		}
+		var encodeErr *EncodeError
+		if !errors.As(err, &encodeErr) || encodeErr.Record != 2 || encodeErr.Field != "id" {
+			t.Errorf("want EncodeError on record 2, field id, got %v", err)
+		}
+	})
+
+	t.Run("header wider than the column", func(t *testing.T) {
+		type narrow struct {
+			Flag string `csv:"flag,width=1"`
+			Name string `csv:"名前です,width=3"`
+		}
+		var buf bytes.Buffer
+		enc := NewFixedWidthEncoder(&buf, true)
+		err := enc.EncodeRecord(narrow{Flag: "Y", Name: "abc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		want := "f名前で\n" +
+			"Yabc\n"
+		if buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("missing width", func(t *testing.T) {
+		type noWidth struct {
+			ID string `csv:"id"`
+		}
+		enc := NewFixedWidthEncoder(&bytes.Buffer{}, false)
+		if err := enc.EncodeRecord(noWidth{}); err == nil {
+			t.Error("want error, got nil")
+		}
+	})
+}
+
+func TestFixedWidthDecoder(t *testing.T) {
+	t.Run("tags", func(t *testing.T) {
+		input := "A1  apple   001234    x\r\n" +
+			"\n" +
+			"B22 orange  -00012\n" +
+			"C   りんご     000000     \n"
+		dec := NewFixedWidthDecoder(strings.NewReader(input))
+		var got []fixedWidthRecord
+		if err := dec.DecodeAll(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := []fixedWidthRecord{
+			{"A1", "apple", 1234, "x"},
+			{"B22", "orange", -12, ""},
+			{"C", "りんご", 0, ""},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("runtime layout", func(t *testing.T) {
+		dec := NewFixedWidthDecoder(strings.NewReader("**7ab  \n"))
+		err := dec.SetLayout([]FixedWidthColumn{
+			{Name: "code", Width: 3, Align: AlignRight, Pad: '*'},
+			{Name: "label", Width: 4},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]string
+		if err := dec.DecodeRecord(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{"code": "7", "label": "ab"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("error position", func(t *testing.T) {
+		dec := NewFixedWidthDecoder(strings.NewReader("A1  apple   00x234    x\n"))
+		var got fixedWidthRecord
+		err := dec.DecodeRecord(&got)
+		var decErr *DecodeError
+		if !errors.As(err, &decErr) {
+			t.Fatalf("want *DecodeError, got %v", err)
+		}
+		if decErr.Line != 1 || decErr.Column != 13 || decErr.Field != "amount" {
+			t.Errorf("unexpected error: %+v", decErr)
+		}
+	})
+
+	t.Run("no layout", func(t *testing.T) {
+		dec := NewFixedWidthDecoder(strings.NewReader("abc\n"))
+		var got map[string]string
+		if err := dec.DecodeRecord(&got); err == nil {
+			t.Error("want error, got nil")
+		}
+	})
+}
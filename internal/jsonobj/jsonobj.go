@@ -0,0 +1,83 @@
+// Package jsonobj handles JSON objects keeping the order of their keys.
+package jsonobj
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Object is a JSON object that keeps the order of its keys.
+type Object struct {
+	Keys   []string
+	Values []json.RawMessage
+}
+
+// Parse parses the JSON object data.
+func Parse(data []byte) (*Object, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("want a JSON object, got %s", short(data))
+	}
+	obj := &Object{}
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err != nil {
+			return nil, err
+		}
+		obj.Keys = append(obj.Keys, tok.(string))
+		obj.Values = append(obj.Values, raw)
+	}
+	if _, err := d.Token(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func short(data []byte) string {
+	if len(data) > 20 {
+		return string(data[:20]) + "..."
+	}
+	return string(data)
+}
+
+// MarshalJSON implements json.Marshaler.
+// The keys are written in the order of Keys.
+func (obj *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range obj.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(obj.Values[i])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Marshal is like json.Marshal, but it doesn't escape HTML characters.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,31 @@
+package jsonobj
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	obj, err := Parse([]byte(`{"b": 1, "a": {"y": null}, "b": "<x>"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b", "a", "b"}; !reflect.DeepEqual(obj.Keys, want) {
+		t.Errorf("got keys %q, want %q", obj.Keys, want)
+	}
+	got, err := Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"b":1,"a":{"y":null},"b":"<x>"}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	for _, input := range []string{`[1]`, `null`, `{"a":`, `"a long string that is not an object"`} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("%s: want error, got nil", input)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package headercsv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/shogo82148/go-header-csv/internal/jsonobj"
+)
+
+// JSONLinesOptions is the options of EncodeJSONLines and DecodeJSONLines.
+type JSONLinesOptions struct {
+	// Rename maps the names to the new names;
+	// the keys of the JSON objects to the column names in EncodeJSONLines,
+	// and the column names to the keys of the JSON objects in DecodeJSONLines.
+	Rename map[string]string
+
+	// Schema is the column types used by DecodeJSONLines.
+	// The values of int, float and bool columns are written as JSON numbers and booleans,
+	// and their empty values are written as null.
+	// The values that don't match the type are written as strings.
+	Schema *Schema
+
+	// InferRecords is the number of records that DecodeJSONLines buffers to infer the schema,
+	// by the same rules as InferSchema. It is ignored if Schema is set.
+	InferRecords int
+}
+
+func (opts *JSONLinesOptions) rename(name string) string {
+	if opts == nil {
+		return name
+	}
+	if to, ok := opts.Rename[name]; ok {
+		return to
+	}
+	return name
+}
+
+// EncodeJSONLines reads JSON objects from r and encodes them as records.
+// The input is JSON Lines, or any sequence of JSON objects separated by white space,
+// or a JSON array of objects.
+//
+// The nested objects are flattened by joining the keys with ".", e.g. {"a":{"b":1}} is the column "a.b".
+// Arrays and empty objects are written as JSON, and null is written as an empty field.
+// The keys are kept in the order of appearance, and an empty object is written as a row of empty fields.
+// If the header is not set and DiscoverHeader is zero, the header is discovered
+// as if DiscoverHeader were -1; it is the union of the keys of all objects and it is written by Flush.
+// DiscoverHeader itself is not modified.
+func (enc *Encoder) EncodeJSONLines(r io.Reader, opts *JSONLinesOptions) error {
+	if enc.header == nil && enc.discovery == nil && enc.DiscoverHeader == 0 {
+		enc.DiscoverHeader = -1
+		defer func() { enc.DiscoverHeader = 0 }()
+	}
+
+	br := bufio.NewReader(r)
+	array, err := startsWithArray(br)
+	if err != nil {
+		return err
+	}
+	d := json.NewDecoder(br)
+	d.UseNumber()
+	if array {
+		if _, err := d.Token(); err != nil {
+			return err
+		}
+	}
+	for {
+		if array && !d.More() {
+			// the end of the array
+			_, err := d.Token()
+			return err
+		}
+
+		var raw json.RawMessage
+		if err := d.Decode(&raw); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		record := &jsonRecord{index: map[string]int{}}
+		if err := record.parse(raw, ""); err != nil {
+			return &EncodeError{
+				Record: enc.records + 1,
+				Err:    err,
+			}
+		}
+		for i, name := range record.names {
+			record.names[i] = opts.rename(name)
+		}
+		record.buildIndex()
+		if err := enc.encodeRecord(reflect.ValueOf(record)); err != nil {
+			return err
+		}
+	}
+}
+
+// startsWithArray reports whether the first non-space character is '['.
+func startsWithArray(br *bufio.Reader) (bool, error) {
+	for {
+		b, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '[', br.UnreadByte()
+	}
+}
+
+// jsonRecord is a flattened JSON object that keeps the order of the keys.
+type jsonRecord struct {
+	names  []string
+	values []string
+	index  map[string]int
+}
+
+// parse flattens the JSON object data into the record.
+func (r *jsonRecord) parse(data []byte, prefix string) error {
+	obj, err := jsonobj.Parse(data)
+	if err != nil {
+		return err
+	}
+	for i, key := range obj.Keys {
+		if err := r.add(prefix+key, obj.Values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *jsonRecord) add(key string, raw json.RawMessage) error {
+	raw = bytes.TrimSpace(raw)
+	switch raw[0] {
+	case '{':
+		before := len(r.names)
+		if err := r.parse(raw, key+"."); err != nil {
+			return err
+		}
+		if len(r.names) > before {
+			return nil
+		}
+		// the empty object
+		r.append(key, "{}")
+	case '[':
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return err
+		}
+		r.append(key, buf.String())
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		r.append(key, s)
+	case 'n':
+		r.append(key, "")
+	default:
+		// numbers and booleans
+		r.append(key, string(raw))
+	}
+	return nil
+}
+
+func (r *jsonRecord) append(key, value string) {
+	r.names = append(r.names, key)
+	r.values = append(r.values, value)
+}
+
+func (r *jsonRecord) buildIndex() {
+	for i, name := range r.names {
+		if _, ok := r.index[name]; !ok {
+			r.index[name] = i
+		}
+	}
+}
+
+var jsonRecordType = reflect.TypeOf((*jsonRecord)(nil))
+
+type jsonRecordInterface struct{}
+
+func (rt *jsonRecordInterface) Field(v reflect.Value, i int, name string) (reflect.Value, *field) {
+	r := v.Interface().(*jsonRecord)
+	j, ok := r.index[name]
+	if !ok {
+		return reflect.Value{}, nil
+	}
+	return reflect.ValueOf(r.values[j]), nil
+}
+
+func (rt *jsonRecordInterface) HeaderNames(v reflect.Value) []string {
+	r := v.Interface().(*jsonRecord)
+	if len(r.names) == 0 {
+		// the empty object is an empty row, not a record without the header.
+		return []string{}
+	}
+	if len(r.index) == len(r.names) {
+		return r.names
+	}
+	// remove the duplicated keys.
+	names := make([]string, 0, len(r.index))
+	for i, name := range r.names {
+		if r.index[name] == i {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (rt *jsonRecordInterface) TypeHeaderNames() []string {
+	return nil
+}
+
+// DecodeJSONLines reads the records and writes them to w as JSON Lines.
+// The keys of the objects are the column names in the order of the header.
+// The values are strings unless Schema or InferRecords is set in opts.
+// Each line is written by a single call of w.Write.
+func (dec *Decoder) DecodeJSONLines(w io.Writer, opts *JSONLinesOptions) error {
+	if opts == nil {
+		opts = &JSONLinesOptions{}
+	}
+	header, err := dec.Header()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var buffered [][]string
+	columns := make([]*ColumnSchema, len(header))
+	if opts.Schema != nil {
+		byName := make(map[string]*ColumnSchema, len(opts.Schema.Columns))
+		for i := range opts.Schema.Columns {
+			byName[opts.Schema.Columns[i].Name] = &opts.Schema.Columns[i]
+		}
+		for i, name := range header {
+			columns[i] = byName[name]
+		}
+	} else if opts.InferRecords > 0 {
+		inference := make([]*columnInference, len(header))
+		for i := range inference {
+			inference[i] = newColumnInference(dec, DefaultTimeLayouts)
+		}
+		for len(buffered) < opts.InferRecords {
+			var row []string
+			if err := dec.DecodeRecord(&row); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return err
+			}
+			for i, value := range row {
+				inference[i].add(value)
+			}
+			buffered = append(buffered, row)
+		}
+		for i, c := range inference {
+			schema := c.schema(header[i])
+			columns[i] = &schema
+		}
+	}
+
+	keys := make([][]byte, len(header))
+	for i, name := range header {
+		key, err := jsonobj.Marshal(opts.rename(name))
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+
+	var buf bytes.Buffer
+	write := func(row []string) error {
+		buf.Reset()
+		buf.WriteByte('{')
+		first := true
+		for i, s := range row {
+			if !dec.columns.selected(header[i]) {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+			value, err := jsonobj.Marshal(jsonValue(s, columns[i]))
+			if err != nil {
+				return err
+			}
+			buf.Write(value)
+		}
+		buf.WriteString("}\n")
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+
+	for _, row := range buffered {
+		if err := write(row); err != nil {
+			return err
+		}
+	}
+	for {
+		var row []string
+		if err := dec.DecodeRecord(&row); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := write(row); err != nil {
+			return err
+		}
+	}
+}
+
+// jsonValue converts the field s into the JSON value of the column type.
+func jsonValue(s string, column *ColumnSchema) any {
+	if column == nil {
+		return s
+	}
+	switch column.Type {
+	case TypeInt, TypeFloat, TypeBool:
+		if s == "" {
+			return nil
+		}
+	}
+	switch column.Type {
+	case TypeInt, TypeFloat:
+		if hasLeadingZero(s) {
+			// zip codes, account numbers and so on; the zeros would be lost in JSON numbers.
+			return s
+		}
+	}
+	switch column.Type {
+	case TypeInt:
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case TypeFloat:
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return f
+		}
+	case TypeBool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
+
+// hasLeadingZero reports whether the number s has redundant leading zeros, such as "007" and "-01.5".
+func hasLeadingZero(s string) bool {
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+	return len(s) > 1 && s[0] == '0' && '0' <= s[1] && s[1] <= '9'
+}
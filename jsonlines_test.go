@@ -0,0 +1,195 @@
+package headercsv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncoder_EncodeJSONLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  *JSONLinesOptions
+		want  string
+	}{
+		{
+			name: "union and order",
+			input: `{"name":"apple","price":1.50,"tags":["a","b"]}
+{"name":"orange","stock":{"count":3,"where":{"city":"Tokyo"}},"price":null}
+`,
+			want: "name,price,tags,stock.count,stock.where.city\n" +
+				"apple,1.50,\"[\"\"a\"\",\"\"b\"\"]\",,\n" +
+				"orange,,,3,Tokyo\n",
+		},
+		{
+			name:  "array",
+			input: ` [{"b":true,"a":{}}, {"a":"x"}] `,
+			want:  "b,a\ntrue,{}\n,x\n",
+		},
+		{
+			name:  "rename",
+			input: `{"a":{"b":1}}`,
+			opts:  &JSONLinesOptions{Rename: map[string]string{"a.b": "ab"}},
+			want:  "ab\n1\n",
+		},
+		{
+			name:  "empty objects",
+			input: "{}\n{\"a\":1}\n{}\n{\"b\":2}\n",
+			want:  "a,b\n,\n1,\n,\n,2\n",
+		},
+		{
+			name:  "empty",
+			input: "\n",
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			if err := enc.EncodeJSONLines(strings.NewReader(tt.input), tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			enc.Flush()
+			if err := enc.Error(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoder_EncodeJSONLines_Header(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.SetHeader([]string{"b", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.EncodeJSONLines(strings.NewReader(`{"a":1,"b":2,"c":3}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if want := "b,a\n2,1\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEncoder_EncodeJSONLines_Options(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.EncodeJSONLines(strings.NewReader(`{"a":1}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.EncodeJSONLines(strings.NewReader(`{"b":2}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	if enc.DiscoverHeader != 0 {
+		t.Errorf("DiscoverHeader is changed to %d", enc.DiscoverHeader)
+	}
+	if enc.MarshalField != nil {
+		t.Error("MarshalField is changed")
+	}
+
+	// the discovery started by EncodeJSONLines continues until Flush.
+	if err := enc.EncodeRecord(map[string]string{"c": "3"}); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if want := "a,b,c\n1,,\n,2,\n,,3\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEncoder_EncodeJSONLines_Error(t *testing.T) {
+	for _, input := range []string{`[1]`, `{"a":1} 2`, `{"a":`} {
+		enc := NewEncoder(&bytes.Buffer{})
+		if err := enc.EncodeJSONLines(strings.NewReader(input), nil); err == nil {
+			t.Errorf("%q: want error, got nil", input)
+		}
+	}
+}
+
+func TestDecoder_DecodeJSONLines(t *testing.T) {
+	const input = "id,name,price,active,date\n" +
+		"1,<apple>,1.5,true,2024-01-02\n" +
+		"2,orange,,false,2024-01-03\n" +
+		"x,banana,2,true,2024-01-04\n"
+	tests := []struct {
+		name string
+		opts *JSONLinesOptions
+		want string
+	}{
+		{
+			name: "strings",
+			want: `{"id":"1","name":"<apple>","price":"1.5","active":"true","date":"2024-01-02"}
+{"id":"2","name":"orange","price":"","active":"false","date":"2024-01-03"}
+{"id":"x","name":"banana","price":"2","active":"true","date":"2024-01-04"}
+`,
+		},
+		{
+			name: "infer",
+			opts: &JSONLinesOptions{InferRecords: 2, Rename: map[string]string{"id": "ID"}},
+			want: `{"ID":1,"name":"<apple>","price":1.5,"active":true,"date":"2024-01-02"}
+{"ID":2,"name":"orange","price":null,"active":false,"date":"2024-01-03"}
+{"ID":"x","name":"banana","price":2,"active":true,"date":"2024-01-04"}
+`,
+		},
+		{
+			name: "schema",
+			opts: &JSONLinesOptions{Schema: &Schema{Columns: []ColumnSchema{{Name: "price", Type: TypeFloat}}}},
+			want: `{"id":"1","name":"<apple>","price":1.5,"active":"true","date":"2024-01-02"}
+{"id":"2","name":"orange","price":null,"active":"false","date":"2024-01-03"}
+{"id":"x","name":"banana","price":2,"active":"true","date":"2024-01-04"}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			dec := NewDecoder(strings.NewReader(input))
+			if err := dec.DecodeJSONLines(&buf, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_DecodeJSONLines_LeadingZeros(t *testing.T) {
+	const input = "zip,code\n" +
+		"02139,0.5\n" +
+		"0x1F,-0.25\n" +
+		"10,007\n" +
+		"0,-01.5\n"
+	schema := &Schema{Columns: []ColumnSchema{{Name: "zip", Type: TypeInt}, {Name: "code", Type: TypeFloat}}}
+	want := `{"zip":"02139","code":0.5}
+{"zip":"0x1F","code":-0.25}
+{"zip":10,"code":"007"}
+{"zip":0,"code":"-01.5"}
+`
+	var buf bytes.Buffer
+	dec := NewDecoder(strings.NewReader(input))
+	if err := dec.DecodeJSONLines(&buf, &JSONLinesOptions{Schema: schema}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestDecoder_DecodeJSONLines_SelectColumns(t *testing.T) {
+	var buf bytes.Buffer
+	dec := NewDecoder(strings.NewReader("a,b,c\n1,2,3\n"))
+	dec.SelectColumns("c", "a")
+	if err := dec.DecodeJSONLines(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"a\":\"1\",\"c\":\"3\"}\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,109 @@
+package headercsv
+
+import (
+	"errors"
+	"reflect"
+)
+
+// RecordMarshaler is the interface implemented by types that can marshal themselves into a CSV record.
+// The headercsv-codegen command generates the implementation for tagged structs.
+//
+// MarshalCSVRecord returns the fields in the order of header.
+// An error on a field should be an *EncodeError with Field set;
+// the encoder fills the record number and the type.
+type RecordMarshaler interface {
+	MarshalCSVRecord(header []string) ([]string, error)
+}
+
+// RecordUnmarshaler is the interface implemented by types that can unmarshal a CSV record into themselves.
+// The headercsv-codegen command generates the implementation for tagged structs.
+//
+// UnmarshalCSVRecord decodes the record whose fields are in the order of header.
+// An error on a field should be a *DecodeError with Field and Err set;
+// the decoder fills the position of the field.
+type RecordUnmarshaler interface {
+	UnmarshalCSVRecord(header, record []string) error
+}
+
+// recordMarshaler returns the RecordMarshaler of v, if the encoder can use it.
+// The fields are encoded by reflection if the sanitization is enabled.
+func (enc *Encoder) recordMarshaler(v reflect.Value) (RecordMarshaler, bool) {
+	if enc.Sanitize != nil {
+		return nil, false
+	}
+	switch {
+	case v.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			return nil, false
+		}
+	case v.CanAddr():
+		v = v.Addr()
+	default:
+		if !reflect.PointerTo(v.Type()).Implements(recordMarshalerType) {
+			return nil, false
+		}
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+	m, ok := v.Interface().(RecordMarshaler)
+	return m, ok
+}
+
+var recordMarshalerType = reflect.TypeOf((*RecordMarshaler)(nil)).Elem()
+
+// marshalRecord encodes v by the RecordMarshaler m.
+func (enc *Encoder) marshalRecord(m RecordMarshaler, v reflect.Value) ([]string, error) {
+	record, err := m.MarshalCSVRecord(enc.header)
+	if err != nil {
+		var encErr *EncodeError
+		if errors.As(err, &encErr) && encErr.Record == 0 {
+			encErr.Record = enc.records + 1
+			if encErr.Type == nil {
+				encErr.Type = v.Type()
+			}
+		}
+		return nil, err
+	}
+	if enc.excel {
+		for i, s := range record {
+			record[i] = quoteExcelText(s)
+		}
+	}
+	return record, nil
+}
+
+// recordUnmarshaler returns the RecordUnmarshaler of v, if the decoder can use it.
+// The fields are decoded by reflection if any per-field feature is enabled.
+func (dec *Decoder) recordUnmarshaler(v reflect.Value) (RecordUnmarshaler, bool) {
+	if dec.ErrorHandler != nil || dec.CollectErrors || dec.columns != nil || !v.CanAddr() {
+		return nil, false
+	}
+	u, ok := v.Addr().Interface().(RecordUnmarshaler)
+	return u, ok
+}
+
+// unmarshalRecord decodes the record into v by the RecordUnmarshaler u.
+func (dec *Decoder) unmarshalRecord(u RecordUnmarshaler, v reflect.Value, record []string) error {
+	err := u.UnmarshalCSVRecord(dec.header, record)
+	if err == nil {
+		return nil
+	}
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) || decErr.Record != 0 {
+		return err
+	}
+	for i, k := range dec.header {
+		if k != decErr.Field || i >= len(record) {
+			continue
+		}
+		// fill the position of the field.
+		fv, _ := recordType(v.Type()).Field(v, i, k)
+		if !fv.IsValid() {
+			fv = reflect.ValueOf(record[i])
+		}
+		var errs DecodeErrors
+		return dec.fieldError(&errs, record, i, k, fv, decErr.Err)
+	}
+	return err
+}
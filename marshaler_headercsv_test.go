@@ -0,0 +1,257 @@
+// Code generated by headercsv-codegen; DO NOT EDIT.
+
+package headercsv
+
+import (
+	"errors"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// MarshalCSVRecord implements RecordMarshaler.
+func (v *codegenRecord) MarshalCSVRecord(header []string) ([]string, error) {
+	record := make([]string, len(header))
+	for i, name := range header {
+		switch name {
+		case "string":
+			record[i] = string(v.String)
+		case "bool":
+			record[i] = strconv.FormatBool(bool(v.Bool))
+		case "int":
+			record[i] = strconv.FormatInt(int64(v.Int), 10)
+		case "int8":
+			record[i] = strconv.FormatInt(int64(v.Int8), 10)
+		case "uint16":
+			record[i] = strconv.FormatUint(uint64(v.Uint16), 10)
+		case "float32":
+			record[i] = strconv.FormatFloat(float64(v.Float32), 'g', -1, 32)
+		case "float64":
+			if v.Float64 != 0 {
+				record[i] = strconv.FormatFloat(float64(v.Float64), 'g', -1, 64)
+			}
+		case "status":
+			record[i] = string(v.Status)
+		case "level":
+			text, err := v.Level.MarshalText()
+			if err != nil {
+				return nil, &EncodeError{Field: name, Err: err}
+			}
+			record[i] = string(text)
+		case "min_level":
+			if v.MinLevel != 0 {
+				text, err := v.MinLevel.MarshalText()
+				if err != nil {
+					return nil, &EncodeError{Field: name, Err: err}
+				}
+				record[i] = string(text)
+			}
+		case "time":
+			text, err := v.Time.MarshalText()
+			if err != nil {
+				return nil, &EncodeError{Field: name, Err: err}
+			}
+			record[i] = string(text)
+		case "date":
+			record[i] = v.Date.Format("2006/01/02")
+		case "ip":
+			text, err := v.IP.MarshalText()
+			if err != nil {
+				return nil, &EncodeError{Field: name, Err: err}
+			}
+			record[i] = string(text)
+		case "ptr_int":
+			if v.PtrInt == nil {
+				record[i] = "null"
+			} else {
+				record[i] = strconv.FormatInt(int64(*v.PtrInt), 10)
+			}
+		case "ptr_str":
+			if v.PtrStr != nil {
+				record[i] = string(*v.PtrStr)
+			}
+		case "ptr_date":
+			if v.PtrDate != nil {
+				record[i] = v.PtrDate.Format("2006/01/02")
+			}
+		case "ptr_ip":
+			if v.PtrIP != nil {
+				text, err := v.PtrIP.MarshalText()
+				if err != nil {
+					return nil, &EncodeError{Field: name, Err: err}
+				}
+				record[i] = string(text)
+			}
+		case "NoTag":
+			record[i] = strconv.FormatInt(int64(v.NoTag), 10)
+		}
+	}
+	return record, nil
+}
+
+// UnmarshalCSVRecord implements RecordUnmarshaler.
+func (v *codegenRecord) UnmarshalCSVRecord(header, record []string) error {
+	for i, name := range header {
+		if i >= len(record) {
+			break
+		}
+		switch name {
+		case "string":
+			v.String = string(record[i])
+		case "bool":
+			b, err := strconv.ParseBool(record[i])
+			if err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+			v.Bool = bool(b)
+		case "int":
+			n, err := strconv.ParseInt(record[i], 0, 64)
+			if err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+			if int64(int(n)) != n {
+				return &DecodeError{Field: name, Err: errors.New("integer overflow")}
+			}
+			v.Int = int(n)
+		case "int8":
+			n, err := strconv.ParseInt(record[i], 0, 64)
+			if err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+			if int64(int8(n)) != n {
+				return &DecodeError{Field: name, Err: errors.New("integer overflow")}
+			}
+			v.Int8 = int8(n)
+		case "uint16":
+			n, err := strconv.ParseUint(record[i], 0, 64)
+			if err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+			if uint64(uint16(n)) != n {
+				return &DecodeError{Field: name, Err: errors.New("unsigned integer overflow")}
+			}
+			v.Uint16 = uint16(n)
+		case "float32":
+			n, err := strconv.ParseFloat(record[i], 32)
+			if err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+			v.Float32 = float32(n)
+		case "float64":
+			n, err := strconv.ParseFloat(record[i], 64)
+			if err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+			v.Float64 = float64(n)
+		case "status":
+			v.Status = codegenStatus(record[i])
+		case "level":
+			if err := v.Level.UnmarshalText([]byte(record[i])); err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+		case "min_level":
+			if err := v.MinLevel.UnmarshalText([]byte(record[i])); err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+		case "time":
+			if err := v.Time.UnmarshalText([]byte(record[i])); err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+		case "date":
+			t, err := time.Parse("2006/01/02", record[i])
+			if err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+			v.Date = t
+		case "ip":
+			if err := v.IP.UnmarshalText([]byte(record[i])); err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+		case "ptr_int":
+			if record[i] == "" {
+				v.PtrInt = nil
+				continue
+			}
+			if v.PtrInt == nil {
+				v.PtrInt = new(int)
+			}
+			n, err := strconv.ParseInt(record[i], 0, 64)
+			if err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+			if int64(int(n)) != n {
+				return &DecodeError{Field: name, Err: errors.New("integer overflow")}
+			}
+			*v.PtrInt = int(n)
+		case "ptr_str":
+			if record[i] == "" {
+				v.PtrStr = nil
+				continue
+			}
+			if v.PtrStr == nil {
+				v.PtrStr = new(string)
+			}
+			*v.PtrStr = string(record[i])
+		case "ptr_date":
+			if record[i] == "" {
+				v.PtrDate = nil
+				continue
+			}
+			if v.PtrDate == nil {
+				v.PtrDate = new(time.Time)
+			}
+			t, err := time.Parse("2006/01/02", record[i])
+			if err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+			*v.PtrDate = t
+		case "ptr_ip":
+			if record[i] == "" {
+				v.PtrIP = nil
+				continue
+			}
+			if v.PtrIP == nil {
+				v.PtrIP = new(netip.Addr)
+			}
+			if err := v.PtrIP.UnmarshalText([]byte(record[i])); err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+		case "NoTag":
+			n, err := strconv.ParseInt(record[i], 0, 64)
+			if err != nil {
+				return &DecodeError{Field: name, Err: err}
+			}
+			if int64(int(n)) != n {
+				return &DecodeError{Field: name, Err: errors.New("integer overflow")}
+			}
+			v.NoTag = int(n)
+		}
+	}
+	return nil
+}
+
+// MarshalCSVRecord implements RecordMarshaler.
+func (v *codegenStatusRecord) MarshalCSVRecord(header []string) ([]string, error) {
+	record := make([]string, len(header))
+	for i, name := range header {
+		switch name {
+		case "status":
+			record[i] = string(v.Status)
+		}
+	}
+	return record, nil
+}
+
+// UnmarshalCSVRecord implements RecordUnmarshaler.
+func (v *codegenStatusRecord) UnmarshalCSVRecord(header, record []string) error {
+	for i, name := range header {
+		if i >= len(record) {
+			break
+		}
+		switch name {
+		case "status":
+			v.Status = codegenStatus(record[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package headercsv
+
+import (
+	"bytes"
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+//go:generate go run ./cmd/headercsv-codegen -type codegenRecord,codegenStatusRecord,codegenMoneyRecord marshaler_test.go
+
+type codegenStatus string
+
+type codegenLevel int
+
+func (l codegenLevel) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("*", int(l))), nil
+}
+
+func (l *codegenLevel) UnmarshalText(text []byte) error {
+	if strings.Trim(string(text), "*") != "" {
+		return errors.New("invalid level")
+	}
+	*l = codegenLevel(len(text))
+	return nil
+}
+
+type codegenRecord struct {
+	String   string        `csv:"string"`
+	Bool     bool          `csv:"bool"`
+	Int      int           `csv:"int"`
+	Int8     int8          `csv:"int8"`
+	Uint16   uint16        `csv:"uint16"`
+	Float32  float32       `csv:"float32"`
+	Float64  float64       `csv:"float64,omitempty"`
+	Status   codegenStatus `csv:"status"`
+	Level    codegenLevel  `csv:"level"`
+	MinLevel codegenLevel  `csv:"min_level,omitempty"`
+	Time     time.Time     `csv:"time"`
+	Date     time.Time     `csv:"date,layout=2006/01/02"`
+	IP       netip.Addr    `csv:"ip"`
+	PtrInt   *int          `csv:"ptr_int"`
+	PtrStr   *string       `csv:"ptr_str,omitempty"`
+	PtrDate  *time.Time    `csv:"ptr_date,layout=2006/01/02,omitempty"`
+	PtrIP    *netip.Addr   `csv:"ptr_ip,omitempty"`
+	Ignored  string        `csv:"-"`
+	NoTag    int
+}
+
+// plainCodegenRecord has the same fields as codegenRecord, but doesn't have the generated methods.
+type plainCodegenRecord codegenRecord
+
+type codegenStatusRecord struct {
+	Status codegenStatus `csv:"status"`
+}
+
+// codegenMoneyRecord is skipped by the generator, because money implements driver.Valuer and sql.Scanner.
+type codegenMoneyRecord struct {
+	Price money `csv:"price"`
+}
+
+func TestCodegen_Implements(t *testing.T) {
+	var _ RecordMarshaler = (*codegenRecord)(nil)
+	var _ RecordUnmarshaler = (*codegenRecord)(nil)
+	if _, ok := any(&plainCodegenRecord{}).(RecordMarshaler); ok {
+		t.Error("plainCodegenRecord must not implement RecordMarshaler")
+	}
+}
+
+func TestCodegen_Valuer(t *testing.T) {
+	if _, ok := any(&codegenMoneyRecord{}).(RecordMarshaler); ok {
+		t.Fatal("codegenMoneyRecord must not implement RecordMarshaler")
+	}
+	if _, ok := any(&codegenMoneyRecord{}).(RecordUnmarshaler); ok {
+		t.Fatal("codegenMoneyRecord must not implement RecordUnmarshaler")
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.EncodeAll([]codegenMoneyRecord{{Price: 12345}}); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	want := "price\n123.45\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	var got []codegenMoneyRecord
+	if err := NewDecoder(strings.NewReader(want)).DecodeAll(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Price != 12345 {
+		t.Errorf("got %+v, want price 12345", got)
+	}
+}
+
+func codegenInput() []codegenRecord {
+	n := 42
+	s := "pointer"
+	d := time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)
+	ip := netip.MustParseAddr("2001:db8::1")
+	return []codegenRecord{
+		{
+			String:   "hello, world",
+			Bool:     true,
+			Int:      -123,
+			Int8:     12,
+			Uint16:   65535,
+			Float32:  1.1,
+			Float64:  3.14159,
+			Status:   "active",
+			Level:    3,
+			MinLevel: 1,
+			Time:     time.Date(2024, 1, 2, 3, 4, 5, 6, time.FixedZone("JST", 9*60*60)),
+			Date:     time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			IP:       netip.MustParseAddr("192.0.2.1"),
+			PtrInt:   &n,
+			PtrStr:   &s,
+			PtrDate:  &d,
+			PtrIP:    &ip,
+			Ignored:  "ignored",
+			NoTag:    7,
+		},
+		{
+			Float64: 0.5,
+			PtrInt:  new(int),
+			Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			IP:      netip.MustParseAddr("192.0.2.2"),
+		},
+	}
+}
+
+func TestCodegen_Encode(t *testing.T) {
+	// nil pointers are encoded as "null" without omitempty.
+	in := append(codegenInput(), codegenRecord{})
+	plain := make([]plainCodegenRecord, len(in))
+	for i, r := range in {
+		plain[i] = plainCodegenRecord(r)
+	}
+
+	encode := func(v any, excel bool) string {
+		t.Helper()
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		if excel {
+			enc = NewEncoderExcel(&buf)
+		}
+		if err := enc.EncodeAll(v); err != nil {
+			t.Fatal(err)
+		}
+		for i := range in {
+			// EncodeRecord with non-addressable values
+			if err := enc.EncodeRecord(reflect.ValueOf(v).Index(i).Interface()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		enc.Flush()
+		if err := enc.Error(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	for _, excel := range []bool{false, true} {
+		generated := encode(in, excel)
+		reflective := encode(plain, excel)
+		if generated != reflective {
+			t.Errorf("excel=%t: generated and reflective outputs differ:\ngenerated:  %q\nreflective: %q", excel, generated, reflective)
+		}
+	}
+}
+
+func TestCodegen_Decode(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.EncodeAll(codegenInput()); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	input := buf.String()
+
+	var generated []codegenRecord
+	if err := NewDecoder(strings.NewReader(input)).DecodeAll(&generated); err != nil {
+		t.Fatal(err)
+	}
+	var reflective []plainCodegenRecord
+	if err := NewDecoder(strings.NewReader(input)).DecodeAll(&reflective); err != nil {
+		t.Fatal(err)
+	}
+	if len(generated) != len(reflective) {
+		t.Fatalf("got %d records, want %d", len(generated), len(reflective))
+	}
+	for i := range generated {
+		if !reflect.DeepEqual(plainCodegenRecord(generated[i]), reflective[i]) {
+			t.Errorf("record %d: generated %+v, reflective %+v", i, generated[i], reflective[i])
+		}
+	}
+}
+
+func TestCodegen_DecodeError(t *testing.T) {
+	inputs := []string{
+		"int,string\n1,a\nx,b\n",
+		"int8\n128\n",
+		"uint16\n-1\n",
+		"bool\nmaybe\n",
+		"level\n**-\n",
+		"date\n2024-01-02\n",
+		"ip\nnot an ip\n",
+		"ptr_int\n1.5\n",
+		"string,float32\na,1e100\n",
+	}
+	for _, input := range inputs {
+		var generated []codegenRecord
+		errGenerated := NewDecoder(strings.NewReader(input)).DecodeAll(&generated)
+		var reflective []plainCodegenRecord
+		errReflective := NewDecoder(strings.NewReader(input)).DecodeAll(&reflective)
+
+		var got, want *DecodeError
+		if !errors.As(errGenerated, &got) || !errors.As(errReflective, &want) {
+			t.Errorf("%q: want DecodeError, got %v and %v", input, errGenerated, errReflective)
+			continue
+		}
+		if got.Error() != want.Error() || got.Record != want.Record || got.Value != want.Value || got.Type != want.Type {
+			t.Errorf("%q: generated %#v, reflective %#v", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package headercsv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"reflect"
+)
+
+// maxPreambleRecords is the maximum number of records that DetectHeader skips.
+const maxPreambleRecords = 100
+
+// Preamble returns the records skipped before the header,
+// by SkipLines, SkipLine and DetectHeader.
+// It is available after the header is read.
+func (dec *Decoder) Preamble() [][]string {
+	return dec.preamble
+}
+
+// expectedHeader returns the column names that DetectHeader looks for.
+func (dec *Decoder) expectedHeader(t reflect.Type) (map[string]bool, error) {
+	var names []string
+	if dec.columns != nil && len(dec.columns.include) > 0 {
+		names = dec.columns.include
+	} else if t != nil {
+		names = recordType(t).TypeHeaderNames()
+	}
+	if len(names) == 0 {
+		typ := "<nil>"
+		if t != nil {
+			typ = t.String()
+		}
+		return nil, fmt.Errorf("headercsv: cannot detect the header of type %s", typ)
+	}
+	expected := make(map[string]bool, len(names))
+	for _, name := range names {
+		expected[name] = true
+	}
+	return expected, nil
+}
+
+// isPreamble reports whether the record is a part of the preamble.
+func (dec *Decoder) isPreamble(record []string, expected map[string]bool) bool {
+	if len(dec.preamble) < dec.SkipLines {
+		return true
+	}
+	if dec.SkipLine != nil && dec.SkipLine(record) {
+		return true
+	}
+	if expected != nil {
+		return !matchHeader(record, expected)
+	}
+	return false
+}
+
+// skipPreamble skips the records by SkipLines and SkipLine if the header is set by SetHeader.
+// The first record after the preamble is read again by the next Read.
+func (dec *Decoder) skipPreamble() error {
+	if dec.skipped || (dec.SkipLines <= 0 && dec.SkipLine == nil) {
+		return nil
+	}
+	dec.skipped = true
+
+	restore := dec.loosen()
+	for {
+		record, err := dec.r.Read()
+		if err != nil {
+			restore(0)
+			return err
+		}
+		if !dec.isPreamble(record, nil) {
+			restore(len(dec.header))
+			dec.r = newPendingReader(dec.r, record)
+			return nil
+		}
+		dec.preamble = append(dec.preamble, append([]string(nil), record...))
+	}
+}
+
+// pendingReader returns the record read ahead, and then reads from r.
+type pendingReader struct {
+	r       recordReader
+	pending *bufferedRecord
+	cur     *bufferedRecord
+}
+
+// newPendingReader returns a reader that returns the record first.
+// The record is read with the loosened settings,
+// so the number of fields is checked here as csv.Reader does.
+func newPendingReader(r recordReader, record []string) *pendingReader {
+	// copy the record because csv.Reader may reuse it.
+	record = append([]string(nil), record...)
+	positions := make([][2]int, len(record))
+	for i := range record {
+		line, column := r.FieldPos(i)
+		positions[i] = [2]int{line, column}
+	}
+	var err error
+	if cr, ok := r.(*csv.Reader); ok && cr.FieldsPerRecord > 0 && len(record) != cr.FieldsPerRecord {
+		line, _ := r.FieldPos(0)
+		err = &csv.ParseError{StartLine: line, Line: line, Column: 1, Err: csv.ErrFieldCount}
+	}
+	return &pendingReader{
+		r: r,
+		pending: &bufferedRecord{
+			record:    record,
+			err:       err,
+			positions: positions,
+		},
+	}
+}
+
+func (r *pendingReader) Read() ([]string, error) {
+	r.cur, r.pending = r.pending, nil
+	if r.cur != nil {
+		return r.cur.record, r.cur.err
+	}
+	return r.r.Read()
+}
+
+func (r *pendingReader) FieldPos(field int) (line, column int) {
+	if r.cur == nil {
+		return r.r.FieldPos(field)
+	}
+	if field < 0 || field >= len(r.cur.positions) {
+		panic("out of range index passed to FieldPos")
+	}
+	pos := r.cur.positions[field]
+	return pos[0], pos[1]
+}
+
+// matchHeader reports whether the record contains at least half of the expected column names.
+// The names are compared exactly, as the decoder maps the columns by the header.
+func matchHeader(record []string, expected map[string]bool) bool {
+	found := make(map[string]bool, len(expected))
+	for _, name := range record {
+		if expected[name] {
+			found[name] = true
+		}
+	}
+	return len(found) > 0 && 2*len(found) >= len(expected)
+}
+
+// loosen relaxes the csv.Reader to read the preamble,
+// which may have a different number of fields and bare quotes.
+// The returned function restores the settings; it gets the number of fields in the header.
+func (dec *Decoder) loosen() func(fields int) {
+	cr, ok := dec.r.(*csv.Reader)
+	if !ok || (dec.SkipLines <= 0 && dec.SkipLine == nil && !dec.DetectHeader) {
+		return func(int) {}
+	}
+	fieldsPerRecord, lazyQuotes := cr.FieldsPerRecord, cr.LazyQuotes
+	if fieldsPerRecord == 0 {
+		cr.FieldsPerRecord = -1
+	}
+	cr.LazyQuotes = true
+	return func(fields int) {
+		cr.LazyQuotes = lazyQuotes
+		if fieldsPerRecord == 0 {
+			// the header decides the number of fields, as csv.Reader does.
+			cr.FieldsPerRecord = fields
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package headercsv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type preambleRecord struct {
+	Date   string `csv:"date"`
+	Amount int    `csv:"amount"`
+	Memo   string `csv:"memo"`
+}
+
+const preambleInput = `Account Statement
+Bank of "Example"
+Period,2024-01-01,2024-01-31
+
+date,amount,memo
+2024-01-02,100,coffee
+2024-01-03,200,lunch
+`
+
+var preambleWant = []preambleRecord{
+	{"2024-01-02", 100, "coffee"},
+	{"2024-01-03", 200, "lunch"},
+}
+
+var preambleSkipped = [][]string{
+	{"Account Statement"},
+	{`Bank of "Example"`},
+	{"Period", "2024-01-01", "2024-01-31"},
+}
+
+func TestDecoder_SkipLines(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(preambleInput))
+	dec.SkipLines = 3
+	var got []preambleRecord
+	if err := dec.DecodeAll(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, preambleWant) {
+		t.Errorf("got %v, want %v", got, preambleWant)
+	}
+	if !reflect.DeepEqual(dec.Preamble(), preambleSkipped) {
+		t.Errorf("got preamble %q, want %q", dec.Preamble(), preambleSkipped)
+	}
+}
+
+func TestDecoder_SkipLine(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(preambleInput))
+	dec.SkipLine = func(record []string) bool {
+		return record[0] != "date"
+	}
+	var got []preambleRecord
+	if err := dec.DecodeAll(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, preambleWant) {
+		t.Errorf("got %v, want %v", got, preambleWant)
+	}
+	if !reflect.DeepEqual(dec.Preamble(), preambleSkipped) {
+		t.Errorf("got preamble %q, want %q", dec.Preamble(), preambleSkipped)
+	}
+}
+
+func TestDecoder_DetectHeader(t *testing.T) {
+	t.Run("struct", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader(preambleInput))
+		dec.DetectHeader = true
+		var got []preambleRecord
+		if err := dec.DecodeAll(&got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, preambleWant) {
+			t.Errorf("got %v, want %v", got, preambleWant)
+		}
+		if !reflect.DeepEqual(dec.Preamble(), preambleSkipped) {
+			t.Errorf("got preamble %q, want %q", dec.Preamble(), preambleSkipped)
+		}
+	})
+
+	t.Run("selected columns", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader(preambleInput))
+		dec.DetectHeader = true
+		dec.SelectColumns("date", "memo")
+		var got map[string]string
+		if err := dec.DecodeRecord(&got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{"date": "2024-01-02", "memo": "coffee"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("field count is fixed by the header", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader(preambleInput + "2024-01-04,300\n"))
+		dec.DetectHeader = true
+		var got []preambleRecord
+		err := dec.DecodeAll(&got)
+		if err == nil || !strings.Contains(err.Error(), "wrong number of fields") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader("a,b\n1,2\n"))
+		dec.DetectHeader = true
+		var got []preambleRecord
+		err := dec.DecodeAll(&got)
+		if err == nil || errors.Is(err, io.EOF) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("names with spaces", func(t *testing.T) {
+		// the names must match exactly; " date " is not mapped to the field date.
+		dec := NewDecoder(strings.NewReader("Report\n date , amount , memo \n2024-01-02,100,coffee\n"))
+		dec.DetectHeader = true
+		var got []preambleRecord
+		err := dec.DecodeAll(&got)
+		if err == nil || !strings.Contains(err.Error(), "the header is not found") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("map without columns", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader(preambleInput))
+		dec.DetectHeader = true
+		var got map[string]string
+		if err := dec.DecodeRecord(&got); err == nil {
+			t.Error("want error, got nil")
+		}
+	})
+}
+
+func TestDecoder_SetHeaderSkipLines(t *testing.T) {
+	const input = "Account Statement\nPeriod,2024-01-01,2024-01-31\n2024-01-02,100,coffee\n2024-01-03,200,lunch\n"
+	skipped := [][]string{
+		{"Account Statement"},
+		{"Period", "2024-01-01", "2024-01-31"},
+	}
+
+	t.Run("SkipLines", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader(input))
+		if err := dec.SetHeader([]string{"date", "amount", "memo"}); err != nil {
+			t.Fatal(err)
+		}
+		dec.SkipLines = 2
+		var got []preambleRecord
+		if err := dec.DecodeAll(&got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, preambleWant) {
+			t.Errorf("got %v, want %v", got, preambleWant)
+		}
+		if !reflect.DeepEqual(dec.Preamble(), skipped) {
+			t.Errorf("got preamble %q, want %q", dec.Preamble(), skipped)
+		}
+	})
+
+	t.Run("SkipLine", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader(input))
+		if err := dec.SetHeader([]string{"date", "amount", "memo"}); err != nil {
+			t.Fatal(err)
+		}
+		dec.SkipLine = func(record []string) bool {
+			return !strings.HasPrefix(record[0], "2024-")
+		}
+		var got []preambleRecord
+		for {
+			var r preambleRecord
+			if err := dec.DecodeRecord(&r); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, r)
+		}
+		if !reflect.DeepEqual(got, preambleWant) {
+			t.Errorf("got %v, want %v", got, preambleWant)
+		}
+		if !reflect.DeepEqual(dec.Preamble(), skipped) {
+			t.Errorf("got preamble %q, want %q", dec.Preamble(), skipped)
+		}
+	})
+
+	t.Run("field count of the first record", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader("title\n2024-01-02,100\n"))
+		if err := dec.SetHeader([]string{"date", "amount", "memo"}); err != nil {
+			t.Fatal(err)
+		}
+		dec.SkipLines = 1
+		var got []preambleRecord
+		err := dec.DecodeAll(&got)
+		if err == nil || !strings.Contains(err.Error(), "wrong number of fields") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
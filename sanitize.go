@@ -0,0 +1,39 @@
+package headercsv
+
+import "errors"
+
+// ErrFormulaInjection is returned by SanitizeReject.
+var ErrFormulaInjection = errors.New("headercsv: the field may be interpreted as a formula")
+
+// SanitizePrefix is a policy for Encoder.Sanitize.
+// It prefixes field with a single quote so that spreadsheet applications treat it as text.
+func SanitizePrefix(field string) (string, error) {
+	return "'" + field, nil
+}
+
+// SanitizeReject is a policy for Encoder.Sanitize.
+// It rejects field with ErrFormulaInjection.
+func SanitizeReject(field string) (string, error) {
+	return "", ErrFormulaInjection
+}
+
+// sanitize applies the sanitization policy to s if s may be interpreted as a formula.
+func (enc *Encoder) sanitize(s string, opt *field) (string, error) {
+	if enc.Sanitize == nil || (opt != nil && opt.noSanitize) || !isFormula(s) {
+		return s, nil
+	}
+	return enc.Sanitize(s)
+}
+
+// isFormula reports whether s may be interpreted as a formula by spreadsheet applications.
+// See https://owasp.org/www-community/attacks/CSV_Injection
+func isFormula(s string) bool {
+	if s == "" {
+		return false
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return true
+	}
+	return false
+}
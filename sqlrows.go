@@ -0,0 +1,164 @@
+package headercsv
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// nullTypes maps the nullable types of database/sql to the types of their values.
+var nullTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+	reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+	reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(byte(0)),
+	reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+	reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+	reflect.TypeOf(sql.NullTime{}):    reflect.TypeOf(time.Time{}),
+}
+
+var (
+	rawBytesType = reflect.TypeOf(sql.RawBytes(nil))
+	bytesType    = reflect.TypeOf([]byte(nil))
+	anyType      = reflect.TypeOf((*any)(nil)).Elem()
+)
+
+// EncodeRows writes the rows of a query result as CSV records.
+// The header is the column names of rows, unless it has been set.
+//
+// The values are scanned into the Go types reported by rows.ColumnTypes,
+// and formatted by the same rules as the fields of structs.
+// The nullable types, such as sql.NullInt64, are scanned into their value types,
+// []byte and sql.RawBytes are written as strings, and NULL is written as an empty field.
+// EncodeRows doesn't close rows, but rows are closed when all rows have been read.
+func (enc *Encoder) EncodeRows(rows *sql.Rows) error {
+	return enc.EncodeRowsContext(context.Background(), rows)
+}
+
+// EncodeRowsContext is like EncodeRows, but it stops encoding when ctx is done.
+// The returned error is a *CanceledError that wraps ctx.Err().
+func (enc *Encoder) EncodeRowsContext(ctx context.Context, rows *sql.Rows) error {
+	if enc.MarshalField == nil {
+		enc.MarshalField = json.Marshal
+	}
+
+	names, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	record := &sqlRecord{
+		names:  names,
+		index:  make(map[string]int, len(names)),
+		values: make([]reflect.Value, len(names)),
+		dest:   make([]any, len(names)),
+	}
+	for i, name := range names {
+		if _, ok := record.index[name]; !ok {
+			record.index[name] = i
+		}
+		// scan into a pointer, so NULL is scanned as nil.
+		v := reflect.New(reflect.PointerTo(scanType(types[i])))
+		record.values[i] = v.Elem()
+		record.dest[i] = v.Interface()
+	}
+
+	for rows.Next() {
+		if err := enc.checkContext(ctx); err != nil {
+			return err
+		}
+		if err := rows.Scan(record.dest...); err != nil {
+			return &EncodeError{
+				Record: enc.records + 1,
+				Err:    err,
+			}
+		}
+		if err := enc.encodeRecord(reflect.ValueOf(record)); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if enc.header == nil && enc.discovery == nil {
+		// no rows; write the header only.
+		header, err := enc.columns.apply(names, true)
+		if err != nil {
+			return err
+		}
+		return enc.setHeader(header, true)
+	}
+	return nil
+}
+
+// scanType returns the type that the column is scanned into.
+func scanType(ct *sql.ColumnType) reflect.Type {
+	t := ct.ScanType()
+	if t == nil {
+		return anyType
+	}
+	if nt, ok := nullTypes[t]; ok {
+		return nt
+	}
+	if t == rawBytesType || t == bytesType {
+		return reflect.TypeOf("")
+	}
+	return t
+}
+
+// sqlRecord is a row of a query result.
+type sqlRecord struct {
+	names  []string
+	index  map[string]int  // the first index of the column names
+	values []reflect.Value // the scanned values; they are pointers
+	dest   []any           // the destinations of Scan
+}
+
+var sqlRecordType = reflect.TypeOf((*sqlRecord)(nil))
+
+type sqlRecordInterface struct{}
+
+func (rt *sqlRecordInterface) Field(v reflect.Value, i int, name string) (reflect.Value, *field) {
+	r := v.Interface().(*sqlRecord)
+	if i >= len(r.names) || r.names[i] != name {
+		// the header is projected or reordered.
+		j, ok := r.index[name]
+		if !ok {
+			return reflect.Value{}, nil
+		}
+		i = j
+	}
+	fv := r.values[i]
+	if fv.IsNil() {
+		// NULL
+		return reflect.Value{}, nil
+	}
+	fv = fv.Elem()
+	if fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return reflect.Value{}, nil
+		}
+		if b, ok := fv.Interface().([]byte); ok {
+			return reflect.ValueOf(string(b)), nil
+		}
+		// the untyped column; encode the value by its dynamic type.
+		return fv.Elem(), nil
+	}
+	return fv, nil
+}
+
+func (rt *sqlRecordInterface) HeaderNames(v reflect.Value) []string {
+	return v.Interface().(*sqlRecord).names
+}
+
+func (rt *sqlRecordInterface) TypeHeaderNames() []string {
+	return nil
+}
@@ -0,0 +1,260 @@
+package headercsv
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeTable is the result of a query to the fake driver.
+type fakeTable struct {
+	columns   []string
+	scanTypes []reflect.Type // nil if the driver doesn't report the scan types
+	rows      [][]driver.Value
+}
+
+// fakeTables are the tables of the fake driver, keyed by the query.
+var fakeTables = map[string]*fakeTable{
+	"typed": {
+		columns: []string{"id", "name", "score", "created", "active"},
+		scanTypes: []reflect.Type{
+			reflect.TypeOf(int64(0)),
+			reflect.TypeOf(sql.RawBytes(nil)),
+			reflect.TypeOf(sql.NullFloat64{}),
+			reflect.TypeOf(time.Time{}),
+			reflect.TypeOf(false),
+		},
+		rows: [][]driver.Value{
+			{int64(1), []byte("alice"), 1.5, time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC), true},
+			{int64(2), []byte("bob, jr."), nil, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), false},
+			{int64(3), nil, 0.25, nil, nil},
+		},
+	},
+	"untyped": {
+		columns: []string{"id", "name", "score"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("alice"), 1.5},
+			{"2", "bob", nil},
+		},
+	},
+	"duplicated": {
+		columns: []string{"id", "name", "id"},
+		rows: [][]driver.Value{
+			{int64(1), "alice", int64(10)},
+		},
+	},
+	"empty": {
+		columns:   []string{"id", "name"},
+		scanTypes: []reflect.Type{reflect.TypeOf(int64(0)), reflect.TypeOf("")},
+	},
+	"invalid": {
+		columns:   []string{"id"},
+		scanTypes: []reflect.Type{reflect.TypeOf(int64(0))},
+		rows: [][]driver.Value{
+			{int64(1)},
+			{"abc"},
+		},
+	},
+}
+
+func init() {
+	sql.Register("headercsv-fake", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	table, ok := fakeTables[query]
+	if !ok {
+		return nil, fmt.Errorf("unknown table: %s", query)
+	}
+	return fakeStmt{table: table}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type fakeStmt struct {
+	table *fakeTable
+}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return 0
+}
+
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.table.scanTypes == nil {
+		return &fakeRows{table: s.table}, nil
+	}
+	return &fakeTypedRows{fakeRows{table: s.table}}, nil
+}
+
+type fakeRows struct {
+	table *fakeTable
+	next  int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.table.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.table.rows) {
+		return io.EOF
+	}
+	copy(dest, r.table.rows[r.next])
+	r.next++
+	return nil
+}
+
+// fakeTypedRows reports the scan types of the columns.
+type fakeTypedRows struct {
+	fakeRows
+}
+
+func (r *fakeTypedRows) ColumnTypeScanType(index int) reflect.Type {
+	return r.table.scanTypes[index]
+}
+
+func queryFake(t *testing.T, query string) *sql.Rows {
+	t.Helper()
+	db, err := sql.Open("headercsv-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rows.Close() })
+	return rows
+}
+
+func TestEncodeRows(t *testing.T) {
+	testcases := []struct {
+		name    string
+		query   string
+		columns []string
+		want    string
+	}{
+		{
+			name:  "typed",
+			query: "typed",
+			want: "id,name,score,created,active\n" +
+				"1,alice,1.5,2024-02-29T12:30:00Z,true\n" +
+				"2,\"bob, jr.\",,2024-03-01T00:00:00Z,false\n" +
+				"3,,0.25,,\n",
+		},
+		{
+			name:  "untyped",
+			query: "untyped",
+			want: "id,name,score\n" +
+				"1,alice,1.5\n" +
+				"2,bob,\n",
+		},
+		{
+			name:    "select columns",
+			query:   "typed",
+			columns: []string{"name", "id"},
+			want: "name,id\n" +
+				"alice,1\n" +
+				"\"bob, jr.\",2\n" +
+				",3\n",
+		},
+		{
+			name:  "duplicated columns",
+			query: "duplicated",
+			want: "id,name,id\n" +
+				"1,alice,10\n",
+		},
+		{
+			name:  "empty",
+			query: "empty",
+			want:  "id,name\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf)
+			if tc.columns != nil {
+				if err := enc.SelectColumns(tc.columns...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := enc.EncodeRows(queryFake(t, tc.query)); err != nil {
+				t.Fatal(err)
+			}
+			enc.Flush()
+			if err := enc.Error(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tc.want {
+				t.Errorf("got %q, want %q", buf.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestEncodeRows_error(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	err := enc.EncodeRows(queryFake(t, "invalid"))
+
+	var encodeErr *EncodeError
+	if !errors.As(err, &encodeErr) {
+		t.Fatalf("want EncodeError, got %v", err)
+	}
+	if encodeErr.Record != 2 {
+		t.Errorf("got record %d, want 2", encodeErr.Record)
+	}
+}
+
+func TestEncodeRowsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	err := enc.EncodeRowsContext(ctx, queryFake(t, "typed"))
+
+	var canceledErr *CanceledError
+	if !errors.As(err, &canceledErr) {
+		t.Fatalf("want CanceledError, got %v", err)
+	}
+	if canceledErr.Records != 0 {
+		t.Errorf("got %d records, want 0", canceledErr.Records)
+	}
+}
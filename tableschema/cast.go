@@ -0,0 +1,365 @@
+package tableschema
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	defaultTrueValues  = []string{"true", "True", "TRUE", "1"}
+	defaultFalseValues = []string{"false", "False", "FALSE", "0"}
+
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	durationPattern = regexp.MustCompile(`^P(?:\d+Y)?(?:\d+M)?(?:\d+W)?(?:\d+D)?(?:T(?:\d+H)?(?:\d+M)?(?:\d+(?:\.\d+)?S)?)?$`)
+)
+
+// layouts of the "any" format of the date, time and datetime types.
+var (
+	anyDateLayouts = []string{
+		"2006-01-02",
+		"2006/01/02",
+		"01/02/2006",
+		"02 Jan 2006",
+		"Jan 2, 2006",
+		"January 2, 2006",
+	}
+	anyTimeLayouts = []string{
+		"15:04:05",
+		"15:04",
+		"3:04:05 PM",
+		"3:04 PM",
+	}
+	anyDatetimeLayouts = []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05Z07:00",
+		"2006-01-02 15:04:05",
+		time.RFC1123Z,
+		time.RFC1123,
+	}
+)
+
+// caster returns the function that casts a value to the type of the field f.
+func caster(f *Field) (func(s string) (any, error), error) {
+	format := strings.TrimPrefix(f.Format, "fmt:")
+	if format == "" {
+		format = "default"
+	}
+
+	switch f.Type {
+	case "", TypeString:
+		return stringCaster(format)
+
+	case TypeInteger:
+		clean, err := numberCleaner(f, false)
+		if err != nil {
+			return nil, err
+		}
+		return func(s string) (any, error) {
+			n, err := strconv.ParseInt(clean(s), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an integer", s)
+			}
+			return n, nil
+		}, nil
+
+	case TypeNumber:
+		clean, err := numberCleaner(f, true)
+		if err != nil {
+			return nil, err
+		}
+		return func(s string) (any, error) {
+			n, err := strconv.ParseFloat(clean(s), 64)
+			if err != nil && !errors.Is(err, strconv.ErrRange) {
+				return nil, fmt.Errorf("%q is not a number", s)
+			}
+			return n, nil
+		}, nil
+
+	case TypeBoolean:
+		trueValues, falseValues := f.TrueValues, f.FalseValues
+		if trueValues == nil {
+			trueValues = defaultTrueValues
+		}
+		if falseValues == nil {
+			falseValues = defaultFalseValues
+		}
+		return func(s string) (any, error) {
+			for _, v := range trueValues {
+				if s == v {
+					return true, nil
+				}
+			}
+			for _, v := range falseValues {
+				if s == v {
+					return false, nil
+				}
+			}
+			return nil, fmt.Errorf("%q is not a boolean", s)
+		}, nil
+
+	case TypeObject:
+		return func(s string) (any, error) {
+			var v map[string]any
+			if err := json.Unmarshal([]byte(s), &v); err != nil || v == nil {
+				return nil, fmt.Errorf("%q is not a JSON object", s)
+			}
+			return s, nil
+		}, nil
+
+	case TypeArray:
+		return func(s string) (any, error) {
+			var v []any
+			if err := json.Unmarshal([]byte(s), &v); err != nil || v == nil {
+				return nil, fmt.Errorf("%q is not a JSON array", s)
+			}
+			return s, nil
+		}, nil
+
+	case TypeDate:
+		return timeCaster(format, "date", "2006-01-02", anyDateLayouts)
+	case TypeTime:
+		return timeCaster(format, "time", "15:04:05", anyTimeLayouts)
+	case TypeDatetime:
+		return timeCaster(format, "datetime", time.RFC3339, anyDatetimeLayouts)
+	case TypeYearMonth:
+		return timeCaster("default", "yearmonth", "2006-01", nil)
+
+	case TypeYear:
+		return func(s string) (any, error) {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a year", s)
+			}
+			return n, nil
+		}, nil
+
+	case TypeDuration:
+		return func(s string) (any, error) {
+			if s == "P" || strings.HasSuffix(s, "T") || !durationPattern.MatchString(s) {
+				return nil, fmt.Errorf("%q is not a duration", s)
+			}
+			return s, nil
+		}, nil
+
+	case TypeAny:
+		return func(s string) (any, error) {
+			return s, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", f.Type)
+}
+
+func stringCaster(format string) (func(s string) (any, error), error) {
+	var valid func(s string) bool
+	switch format {
+	case "default":
+		valid = func(s string) bool { return true }
+	case "email":
+		valid = func(s string) bool {
+			addr, err := mail.ParseAddress(s)
+			return err == nil && addr.Address == s
+		}
+	case "uri":
+		valid = func(s string) bool {
+			u, err := url.Parse(s)
+			return err == nil && u.Scheme != ""
+		}
+	case "uuid":
+		valid = uuidPattern.MatchString
+	case "binary":
+		valid = func(s string) bool {
+			_, err := base64.StdEncoding.DecodeString(s)
+			return err == nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q of type string", format)
+	}
+	return func(s string) (any, error) {
+		if !valid(s) {
+			return nil, fmt.Errorf("%q is not a valid %s", s, format)
+		}
+		return s, nil
+	}, nil
+}
+
+// numberCleaner returns the function that converts a number in the format of the field
+// to the format of strconv.
+func numberCleaner(f *Field, decimal bool) (func(s string) string, error) {
+	decimalChar := f.DecimalChar
+	if decimalChar == "" {
+		decimalChar = "."
+	}
+	if decimalChar == f.GroupChar {
+		return nil, errors.New("decimalChar and groupChar must be different")
+	}
+	bare := f.BareNumber == nil || *f.BareNumber
+	return func(s string) string {
+		if f.GroupChar != "" {
+			s = strings.ReplaceAll(s, f.GroupChar, "")
+		}
+		if decimal && decimalChar != "." {
+			s = strings.ReplaceAll(s, decimalChar, ".")
+		}
+		if !bare {
+			// strip the leading and trailing non-numeric characters, such as "$" or "%".
+			s = strings.TrimLeftFunc(s, func(r rune) bool {
+				return !unicode.IsDigit(r) && r != '-' && r != '+' && r != '.'
+			})
+			s = strings.TrimRightFunc(s, func(r rune) bool {
+				return !unicode.IsDigit(r)
+			})
+		}
+		return s
+	}, nil
+}
+
+func timeCaster(format, typ, defaultLayout string, anyLayouts []string) (func(s string) (any, error), error) {
+	var layouts []string
+	switch format {
+	case "default":
+		layouts = []string{defaultLayout}
+	case "any":
+		layouts = anyLayouts
+	default:
+		layout, err := goLayout(format)
+		if err != nil {
+			return nil, err
+		}
+		layouts = []string{layout}
+	}
+	return func(s string) (any, error) {
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a %s", s, typ)
+	}, nil
+}
+
+// key returns the string that identifies the value v for the unique and enum constraints.
+func key(v any) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+// compare returns -1, 0 or +1 depending on whether a < b, a == b or a > b.
+// a and b have the same type.
+func compare(a, b any) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case time.Time:
+		b := b.(time.Time)
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		}
+	}
+	return 0
+}
+
+// directives of strptime and their layouts in the time package.
+var directives = []struct {
+	directive string
+	layout    string
+}{
+	{"%Y", "2006"},
+	{"%y", "06"},
+	{"%m", "01"},
+	{"%d", "02"},
+	{"%e", "_2"},
+	{"%j", "002"},
+	{"%B", "January"},
+	{"%b", "Jan"},
+	{"%A", "Monday"},
+	{"%a", "Mon"},
+	{"%H", "15"},
+	{"%I", "03"},
+	{"%M", "04"},
+	{"%S", "05"},
+	{".%f", ".000000"},
+	{"%p", "PM"},
+	{"%z", "-0700"},
+	{"%Z", "MST"},
+	{"%%", "%"},
+}
+
+// goLayout converts the strptime-style format of a date, time or datetime field,
+// such as "%d/%m/%Y", to the layout of the time package.
+func goLayout(format string) (string, error) {
+	var buf strings.Builder
+	rest := format
+LOOP:
+	for rest != "" {
+		for _, d := range directives {
+			if strings.HasPrefix(rest, d.directive) {
+				buf.WriteString(d.layout)
+				rest = rest[len(d.directive):]
+				continue LOOP
+			}
+		}
+		if rest[0] == '%' {
+			return "", fmt.Errorf("unsupported format %q", format)
+		}
+		buf.WriteByte(rest[0])
+		rest = rest[1:]
+	}
+	return buf.String(), nil
+}
+
+// strptime converts the layout of the time package to the strptime-style format.
+// It reports false if the layout has elements that strptime does not support.
+func strptime(layout string) (string, bool) {
+	var buf strings.Builder
+	rest := layout
+LOOP:
+	for rest != "" {
+		for _, d := range directives {
+			if d.directive != "%%" && strings.HasPrefix(rest, d.layout) {
+				buf.WriteString(d.directive)
+				rest = rest[len(d.layout):]
+				continue LOOP
+			}
+		}
+		switch rest[0] {
+		case '%':
+			buf.WriteString("%%")
+		case '0', '1', '2', '3', '4', '5', '6', '7', '9', 'Z':
+			// other elements of the layout, such as "Z07:00" or "1", are not supported.
+			return "", false
+		default:
+			buf.WriteByte(rest[0])
+		}
+		rest = rest[1:]
+	}
+	return buf.String(), true
+}
@@ -0,0 +1,34 @@
+package tableschema_test
+
+import (
+	"fmt"
+	"strings"
+
+	headercsv "github.com/shogo82148/go-header-csv"
+	"github.com/shogo82148/go-header-csv/tableschema"
+)
+
+func ExampleSchema_Validate() {
+	schema, err := tableschema.Load(strings.NewReader(`{
+  "fields": [
+    {"name": "id", "type": "integer"},
+    {"name": "status", "constraints": {"enum": ["active", "inactive"]}}
+  ],
+  "primaryKey": "id"
+}`))
+	if err != nil {
+		panic(err)
+	}
+
+	in := `id,status
+1,active
+2,deleted
+1,inactive
+`
+	dec := headercsv.NewDecoder(strings.NewReader(in))
+	dec.CollectErrors = true
+	fmt.Println(schema.Validate(dec))
+	// Output:
+	// headercsv: decode error on line 3, column 3, field "status": "deleted" is not one of ["active","inactive"]
+	// headercsv: decode error on line 4, column 1, field "id": the primary key is duplicated
+}
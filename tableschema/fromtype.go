@@ -0,0 +1,263 @@
+package tableschema
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// FromType returns the Table Schema of the records of the struct type t,
+// which is tagged for headercsv.
+//
+// The types of the fields are mapped as the following:
+//
+//	string and encoding.TextUnmarshaler  string
+//	integers                             integer
+//	floats                               number
+//	bool                                 boolean
+//	time.Time                            datetime, or date or time by the layout option
+//	maps and structs                     object
+//	slices and arrays                    array
+//
+// Fields that are not pointers and don't have the omitempty option are required,
+// except for strings. The min, max, len, enum and pattern options are exported as constraints.
+// If a pointer field doesn't have the omitempty option, "null" is a missing value,
+// because the encoder encodes nil as "null".
+func FromType(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("tableschema: %s is not a struct type", t)
+	}
+
+	type orderedField struct {
+		order   int
+		ordered bool
+		field   *Field
+	}
+	fields := make([]orderedField, 0, t.NumField())
+	null := false
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("csv")
+		if tag == "-" || !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = sf.Name
+		}
+		options := parseOptions(opts)
+		if _, ok := options["omitempty"]; !ok && sf.Type.Kind() == reflect.Pointer {
+			null = true
+		}
+		f, err := fieldFromType(name, sf.Type, options)
+		if err != nil {
+			return nil, err
+		}
+		of := orderedField{field: f}
+		if order, ok := options["order"]; ok {
+			n, err := strconv.Atoi(order)
+			if err != nil {
+				return nil, fmt.Errorf("tableschema: field %q: invalid order option: %q", name, order)
+			}
+			of.order, of.ordered = n, true
+		}
+		fields = append(fields, of)
+	}
+	// the same order as the header of headercsv.Encoder.
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].ordered != fields[j].ordered {
+			return fields[i].ordered
+		}
+		return fields[i].order < fields[j].order
+	})
+
+	s := &Schema{
+		Fields: make([]*Field, 0, len(fields)),
+	}
+	for _, f := range fields {
+		s.Fields = append(s.Fields, f.field)
+	}
+	if null {
+		s.MissingValues = []string{"", "null"}
+	}
+	return s, nil
+}
+
+type options map[string]string
+
+// parseOptions parses the options of the csv tag.
+// The pattern option extends to the end of the tag.
+func parseOptions(opts string) options {
+	ret := options{}
+	for opts != "" {
+		if strings.HasPrefix(opts, "pattern=") {
+			ret["pattern"] = strings.TrimPrefix(opts, "pattern=")
+			break
+		}
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		name, value, _ := strings.Cut(opt, "=")
+		ret[name] = value
+	}
+	return ret
+}
+
+func fieldFromType(name string, t reflect.Type, opts options) (*Field, error) {
+	f := &Field{Name: name}
+	_, omitEmpty := opts["omitempty"]
+	required := !omitEmpty
+	if t.Kind() == reflect.Pointer {
+		required = false
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+
+	switch {
+	case t == timeType:
+		layout := opts["layout"]
+		switch layout {
+		case "", time.RFC3339, time.RFC3339Nano:
+			f.Type = TypeDatetime
+		case "2006-01-02":
+			f.Type = TypeDate
+		case "15:04:05":
+			f.Type = TypeTime
+		default:
+			format, ok := strptime(layout)
+			if !ok {
+				return nil, fmt.Errorf("tableschema: field %q: layout %q is not supported", name, layout)
+			}
+			f.Format = format
+			f.Type = timeTypeOf(format)
+		}
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		f.Type = TypeString
+		required = false
+	default:
+		switch t.Kind() {
+		case reflect.String:
+			f.Type = TypeString
+			required = false
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			f.Type = TypeInteger
+		case reflect.Float32, reflect.Float64:
+			f.Type = TypeNumber
+		case reflect.Bool:
+			f.Type = TypeBoolean
+		case reflect.Map, reflect.Struct:
+			f.Type = TypeObject
+		case reflect.Slice, reflect.Array:
+			f.Type = TypeArray
+		default:
+			return nil, fmt.Errorf("tableschema: field %q: unsupported type %s", name, t)
+		}
+	}
+
+	cons := &Constraints{Required: required}
+	if err := constraintsFromOptions(f, t, opts, cons); err != nil {
+		return nil, err
+	}
+	if reflect.DeepEqual(cons, &Constraints{}) {
+		cons = nil
+	}
+	f.Constraints = cons
+	return f, nil
+}
+
+// timeTypeOf returns the type of the strptime-style format.
+func timeTypeOf(format string) string {
+	var date, clock bool
+	for i := 0; i+1 < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'Y', 'y', 'm', 'd', 'e', 'j', 'B', 'b':
+			date = true
+		case 'H', 'I', 'M', 'S', 'p':
+			clock = true
+		}
+	}
+	switch {
+	case date && !clock:
+		return TypeDate
+	case clock && !date:
+		return TypeTime
+	}
+	return TypeDatetime
+}
+
+// constraintsFromOptions converts the validation options of headercsv to the constraints.
+func constraintsFromOptions(f *Field, t reflect.Type, opts options, cons *Constraints) error {
+	number := func(name string) (any, error) {
+		s, ok := opts[name]
+		if !ok {
+			return nil, nil
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("tableschema: field %q: invalid %s option: %q", f.Name, name, s)
+		}
+		return n, nil
+	}
+	min, err := number("min")
+	if err != nil {
+		return err
+	}
+	max, err := number("max")
+	if err != nil {
+		return err
+	}
+	switch f.Type {
+	case TypeInteger, TypeNumber:
+		cons.Minimum, cons.Maximum = min, max
+		if min == nil && f.Type == TypeInteger && t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uintptr {
+			cons.Minimum = 0.0
+		}
+	case TypeString:
+		if min != nil {
+			n := int(min.(float64))
+			cons.MinLength = &n
+		}
+		if max != nil {
+			n := int(max.(float64))
+			cons.MaxLength = &n
+		}
+	}
+
+	if s, ok := opts["len"]; ok {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("tableschema: field %q: invalid len option: %q", f.Name, s)
+		}
+		cons.MinLength, cons.MaxLength = &n, &n
+	}
+	if s, ok := opts["enum"]; ok {
+		for _, v := range strings.Split(s, "|") {
+			cons.Enum = append(cons.Enum, v)
+		}
+	}
+	if s, ok := opts["pattern"]; ok {
+		// the pattern of Table Schema matches the whole value.
+		if !strings.HasPrefix(s, "^") || !strings.HasSuffix(s, "$") {
+			s = ".*(?:" + s + ").*"
+		}
+		cons.Pattern = s
+	}
+	return nil
+}
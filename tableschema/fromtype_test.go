@@ -0,0 +1,170 @@
+package tableschema
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	headercsv "github.com/shogo82148/go-header-csv"
+)
+
+type fromTypeRecord struct {
+	ID      uint              `csv:"id,order=-1"`
+	Name    string            `csv:"name,min=1,max=20"`
+	Code    string            `csv:"code,len=3,pattern=^[A-Z]{3}$"`
+	Status  string            `csv:"status,omitempty,enum=active|inactive"`
+	Score   *float64          `csv:"score,min=0,max=100"`
+	Age     int               `csv:"age,omitempty"`
+	Admin   bool              `csv:"admin"`
+	Born    time.Time         `csv:"born,layout=2006-01-02"`
+	Login   time.Time         `csv:"login,layout=02/01/2006 15:04"`
+	Created time.Time         `csv:"created"`
+	IP      net.IP            `csv:"ip"`
+	Tags    []string          `csv:"tags"`
+	Attrs   map[string]string `csv:"attrs"`
+	Ignored string            `csv:"-"`
+}
+
+func TestFromType(t *testing.T) {
+	s, err := FromType(reflect.TypeOf(&fromTypeRecord{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"fields":[` +
+		`{"name":"id","type":"integer","constraints":{"required":true,"minimum":0}},` +
+		`{"name":"name","type":"string","constraints":{"minLength":1,"maxLength":20}},` +
+		`{"name":"code","type":"string","constraints":{"minLength":3,"maxLength":3,"pattern":"^[A-Z]{3}$"}},` +
+		`{"name":"status","type":"string","constraints":{"enum":["active","inactive"]}},` +
+		`{"name":"score","type":"number","constraints":{"minimum":0,"maximum":100}},` +
+		`{"name":"age","type":"integer"},` +
+		`{"name":"admin","type":"boolean","constraints":{"required":true}},` +
+		`{"name":"born","type":"date","constraints":{"required":true}},` +
+		`{"name":"login","type":"datetime","format":"%d/%m/%Y %H:%M","constraints":{"required":true}},` +
+		`{"name":"created","type":"datetime","constraints":{"required":true}},` +
+		`{"name":"ip","type":"string"},` +
+		`{"name":"tags","type":"array","constraints":{"required":true}},` +
+		`{"name":"attrs","type":"object","constraints":{"required":true}}` +
+		`],"missingValues":["","null"]}`
+	if string(got) != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	// the exported schema validates the records encoded by headercsv.
+	score := 99.5
+	in := []fromTypeRecord{
+		{
+			ID:      1,
+			Name:    "alice",
+			Code:    "ABC",
+			Status:  "active",
+			Score:   &score,
+			Admin:   true,
+			Born:    time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC),
+			Login:   time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC),
+			Created: time.Date(2024, 2, 29, 12, 30, 0, 0, time.UTC),
+			IP:      net.IPv4(192, 0, 2, 1),
+			Tags:    []string{"a"},
+			Attrs:   map[string]string{"k": "v"},
+		},
+		{ID: 2, Name: "bob", Code: "XYZ", Tags: []string{}, Attrs: map[string]string{}},
+	}
+	var buf strings.Builder
+	enc := headercsv.NewEncoder(&buf)
+	if err := enc.EncodeAll(in); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if err := enc.Error(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(headercsv.NewDecoder(strings.NewReader(buf.String()))); err != nil {
+		t.Errorf("%v\n%s", err, buf.String())
+	}
+}
+
+func TestFromType_unanchoredPattern(t *testing.T) {
+	s, err := FromType(reflect.TypeOf(struct {
+		A string `csv:"a,pattern=[0-9],x"`
+	}{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Fields[0].Constraints.Pattern, ".*(?:[0-9],x).*"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFromType_order(t *testing.T) {
+	s, err := FromType(reflect.TypeOf(struct {
+		A string `csv:"a"`
+		B string `csv:"b,order=1"`
+		C string `csv:"c"`
+	}{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range s.Fields {
+		got = append(got, f.Name)
+	}
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFromType_error(t *testing.T) {
+	testcases := []struct {
+		name string
+		typ  reflect.Type
+		want string
+	}{
+		{
+			name: "not struct",
+			typ:  reflect.TypeOf(""),
+			want: "tableschema: string is not a struct type",
+		},
+		{
+			name: "unsupported type",
+			typ: reflect.TypeOf(struct {
+				C chan int `csv:"c"`
+			}{}),
+			want: `tableschema: field "c": unsupported type chan int`,
+		},
+		{
+			name: "unsupported layout",
+			typ: reflect.TypeOf(struct {
+				T time.Time `csv:"t,layout=Jan _2 15:04:05.000"`
+			}{}),
+			want: `tableschema: field "t": layout "Jan _2 15:04:05.000" is not supported`,
+		},
+		{
+			name: "invalid min",
+			typ: reflect.TypeOf(struct {
+				A int `csv:"a,min=x"`
+			}{}),
+			want: `tableschema: field "a": invalid min option: "x"`,
+		},
+		{
+			name: "invalid order",
+			typ: reflect.TypeOf(struct {
+				A int `csv:"a,order=x"`
+			}{}),
+			want: `tableschema: field "a": invalid order option: "x"`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := FromType(tc.typ)
+			if err == nil || err.Error() != tc.want {
+				t.Errorf("got %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// Package tableschema implements Frictionless Table Schema for go-header-csv.
+//
+// A Table Schema describes the columns of a CSV file: their names, types, formats and constraints.
+// Load reads a Table Schema in JSON, Schema.Validate checks CSV records read by a headercsv.Decoder against it,
+// and FromType exports a Table Schema from a struct type tagged for headercsv.
+//
+// See https://specs.frictionlessdata.io/table-schema/ for the specification.
+// Foreign keys and the geopoint and geojson types are not supported.
+package tableschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// Field types.
+const (
+	TypeString    = "string"
+	TypeNumber    = "number"
+	TypeInteger   = "integer"
+	TypeBoolean   = "boolean"
+	TypeObject    = "object"
+	TypeArray     = "array"
+	TypeDate      = "date"
+	TypeTime      = "time"
+	TypeDatetime  = "datetime"
+	TypeYear      = "year"
+	TypeYearMonth = "yearmonth"
+	TypeDuration  = "duration"
+	TypeAny       = "any"
+)
+
+// Schema is a Table Schema.
+type Schema struct {
+	Fields        []*Field   `json:"fields"`
+	PrimaryKey    PrimaryKey `json:"primaryKey,omitempty"`
+	MissingValues []string   `json:"missingValues,omitempty"`
+}
+
+// Field describes a column of the table.
+type Field struct {
+	Name        string       `json:"name"`
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Type        string       `json:"type,omitempty"`   // the default is "string"
+	Format      string       `json:"format,omitempty"` // the default is "default"
+	Constraints *Constraints `json:"constraints,omitempty"`
+
+	// options of the number and integer types.
+	DecimalChar string `json:"decimalChar,omitempty"`
+	GroupChar   string `json:"groupChar,omitempty"`
+	BareNumber  *bool  `json:"bareNumber,omitempty"`
+
+	// options of the boolean type.
+	TrueValues  []string `json:"trueValues,omitempty"`
+	FalseValues []string `json:"falseValues,omitempty"`
+}
+
+// Constraints are the constraints of a field.
+// Minimum, Maximum and the elements of Enum are JSON numbers or strings
+// in the type and format of the field.
+type Constraints struct {
+	Required  bool   `json:"required,omitempty"`
+	Unique    bool   `json:"unique,omitempty"`
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Minimum   any    `json:"minimum,omitempty"`
+	Maximum   any    `json:"maximum,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	Enum      []any  `json:"enum,omitempty"`
+}
+
+// PrimaryKey is the list of the field names of the primary key.
+// In JSON, it is a string or an array of strings.
+type PrimaryKey []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (pk *PrimaryKey) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*pk = PrimaryKey{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return errors.New("tableschema: primaryKey must be a string or an array of strings")
+	}
+	*pk = PrimaryKey(names)
+	return nil
+}
+
+// Load reads a Table Schema in JSON from r.
+func Load(r io.Reader) (*Schema, error) {
+	var s Schema
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("tableschema: %w", err)
+	}
+	if _, err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// LoadFile reads a Table Schema in JSON from the named file.
+func LoadFile(name string) (*Schema, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Field returns the field named name, or nil if it does not exist.
+func (s *Schema) Field(name string) *Field {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// compiled is a Schema ready for validation.
+type compiled struct {
+	columns       []*column
+	primaryKey    []int // indexes of columns
+	missingValues []string
+}
+
+// column is a compiled Field.
+type column struct {
+	*Field
+	cast      func(s string) (any, error)
+	required  bool
+	unique    bool
+	minLength int // -1 if not set
+	maxLength int // -1 if not set
+	minimum   any
+	maximum   any
+	pattern   *regexp.Regexp
+	enum      map[string]bool // the keys of the values
+	enumParam string
+}
+
+func (s *Schema) compile() (*compiled, error) {
+	if len(s.Fields) == 0 {
+		return nil, errors.New("tableschema: no fields")
+	}
+	c := &compiled{
+		columns:       make([]*column, 0, len(s.Fields)),
+		missingValues: s.MissingValues,
+	}
+	if c.missingValues == nil {
+		c.missingValues = []string{""}
+	}
+
+	names := make(map[string]int, len(s.Fields))
+	for i, f := range s.Fields {
+		if f.Name == "" {
+			return nil, fmt.Errorf("tableschema: the name of field %d is empty", i+1)
+		}
+		if _, ok := names[f.Name]; ok {
+			return nil, fmt.Errorf("tableschema: duplicated field %q", f.Name)
+		}
+		names[f.Name] = i
+		col, err := compileField(f)
+		if err != nil {
+			return nil, err
+		}
+		c.columns = append(c.columns, col)
+	}
+
+	for _, name := range s.PrimaryKey {
+		i, ok := names[name]
+		if !ok {
+			return nil, fmt.Errorf("tableschema: primary key field %q is not found", name)
+		}
+		c.primaryKey = append(c.primaryKey, i)
+		c.columns[i].required = true
+	}
+	return c, nil
+}
+
+func compileField(f *Field) (*column, error) {
+	cast, err := caster(f)
+	if err != nil {
+		return nil, fmt.Errorf("tableschema: field %q: %w", f.Name, err)
+	}
+	col := &column{
+		Field:     f,
+		cast:      cast,
+		minLength: -1,
+		maxLength: -1,
+	}
+	cons := f.Constraints
+	if cons == nil {
+		return col, nil
+	}
+
+	col.required = cons.Required
+	col.unique = cons.Unique
+	if cons.MinLength != nil {
+		col.minLength = *cons.MinLength
+	}
+	if cons.MaxLength != nil {
+		col.maxLength = *cons.MaxLength
+	}
+	if cons.Minimum != nil {
+		if col.minimum, err = castConstraint(f, cast, "minimum", cons.Minimum); err != nil {
+			return nil, err
+		}
+	}
+	if cons.Maximum != nil {
+		if col.maximum, err = castConstraint(f, cast, "maximum", cons.Maximum); err != nil {
+			return nil, err
+		}
+	}
+	if typ := f.Type; (col.minimum != nil || col.maximum != nil) && !ordered(typ) {
+		if typ == "" {
+			typ = TypeString
+		}
+		return nil, fmt.Errorf("tableschema: field %q: minimum and maximum are not supported for type %s", f.Name, typ)
+	}
+	if cons.Pattern != "" {
+		// the pattern matches the whole value.
+		col.pattern, err = regexp.Compile("^(?:" + cons.Pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("tableschema: field %q: invalid pattern: %w", f.Name, err)
+		}
+	}
+	if cons.Enum != nil {
+		col.enum = make(map[string]bool, len(cons.Enum))
+		for _, e := range cons.Enum {
+			v, err := castConstraint(f, cast, "enum", e)
+			if err != nil {
+				return nil, err
+			}
+			col.enum[key(v)] = true
+		}
+		b, _ := json.Marshal(cons.Enum)
+		col.enumParam = string(b)
+	}
+	return col, nil
+}
+
+// castConstraint casts the value v of a constraint to the type of the field.
+func castConstraint(f *Field, cast func(string) (any, error), name string, v any) (any, error) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		s = strconv.Itoa(v)
+	case bool:
+		s = strconv.FormatBool(v)
+	default:
+		return nil, fmt.Errorf("tableschema: field %q: invalid %s: %v", f.Name, name, v)
+	}
+	ret, err := cast(s)
+	if err != nil {
+		return nil, fmt.Errorf("tableschema: field %q: invalid %s: %w", f.Name, name, err)
+	}
+	return ret, nil
+}
+
+// ordered reports whether the values of the type are ordered.
+func ordered(typ string) bool {
+	switch typ {
+	case TypeInteger, TypeNumber, TypeDate, TypeTime, TypeDatetime, TypeYear, TypeYearMonth:
+		return true
+	}
+	return false
+}
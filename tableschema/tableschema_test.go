@@ -0,0 +1,155 @@
+package tableschema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	in := `{
+  "fields": [
+    {"name": "id", "type": "integer", "constraints": {"minimum": 1}},
+    {"name": "name", "title": "Name", "constraints": {"required": true, "maxLength": 10}},
+    {"name": "born", "type": "date", "format": "%d/%m/%Y"}
+  ],
+  "primaryKey": "id",
+  "missingValues": ["", "NA"]
+}`
+	s, err := Load(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Fields) != 3 {
+		t.Fatalf("got %d fields, want 3", len(s.Fields))
+	}
+	if got := s.Field("id").Constraints.Minimum; got != 1.0 {
+		t.Errorf("minimum: got %#v, want 1.0", got)
+	}
+	if got := s.Field("name").Title; got != "Name" {
+		t.Errorf("title: got %q, want %q", got, "Name")
+	}
+	if got := s.Field("unknown"); got != nil {
+		t.Errorf("unknown field: got %#v, want nil", got)
+	}
+	if !reflect.DeepEqual(s.PrimaryKey, PrimaryKey{"id"}) {
+		t.Errorf("primary key: got %#v, want %#v", s.PrimaryKey, PrimaryKey{"id"})
+	}
+	if !reflect.DeepEqual(s.MissingValues, []string{"", "NA"}) {
+		t.Errorf("missing values: got %#v", s.MissingValues)
+	}
+}
+
+func TestLoad_primaryKeyArray(t *testing.T) {
+	in := `{"fields": [{"name": "a"}, {"name": "b"}], "primaryKey": ["a", "b"]}`
+	s, err := Load(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.PrimaryKey, PrimaryKey{"a", "b"}) {
+		t.Errorf("got %#v, want %#v", s.PrimaryKey, PrimaryKey{"a", "b"})
+	}
+}
+
+func TestLoad_error(t *testing.T) {
+	testcases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "invalid json",
+			in:   `{"fields": [`,
+			want: "tableschema: unexpected EOF",
+		},
+		{
+			name: "no fields",
+			in:   `{"fields": []}`,
+			want: "tableschema: no fields",
+		},
+		{
+			name: "duplicated field",
+			in:   `{"fields": [{"name": "a"}, {"name": "a"}]}`,
+			want: `tableschema: duplicated field "a"`,
+		},
+		{
+			name: "unsupported type",
+			in:   `{"fields": [{"name": "a", "type": "geopoint"}]}`,
+			want: `tableschema: field "a": unsupported type "geopoint"`,
+		},
+		{
+			name: "unsupported format",
+			in:   `{"fields": [{"name": "a", "format": "ipv4"}]}`,
+			want: `tableschema: field "a": unsupported format "ipv4" of type string`,
+		},
+		{
+			name: "invalid minimum",
+			in:   `{"fields": [{"name": "a", "type": "integer", "constraints": {"minimum": "x"}}]}`,
+			want: `tableschema: field "a": invalid minimum: "x" is not an integer`,
+		},
+		{
+			name: "minimum of string",
+			in:   `{"fields": [{"name": "a", "constraints": {"minimum": "x"}}]}`,
+			want: `tableschema: field "a": minimum and maximum are not supported for type string`,
+		},
+		{
+			name: "invalid pattern",
+			in:   `{"fields": [{"name": "a", "constraints": {"pattern": "("}}]}`,
+			want: `tableschema: field "a": invalid pattern`,
+		},
+		{
+			name: "unknown primary key",
+			in:   `{"fields": [{"name": "a"}], "primaryKey": "b"}`,
+			want: `tableschema: primary key field "b" is not found`,
+		},
+		{
+			name: "invalid primary key",
+			in:   `{"fields": [{"name": "a"}], "primaryKey": 1}`,
+			want: "tableschema: primaryKey must be a string or an array of strings",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tc.in))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestGoLayout(t *testing.T) {
+	testcases := []struct {
+		format string
+		layout string
+	}{
+		{"%Y-%m-%d", "2006-01-02"},
+		{"%d/%m/%y", "02/01/06"},
+		{"%Y-%m-%dT%H:%M:%S.%f%z", "2006-01-02T15:04:05.000000-0700"},
+		{"%I:%M %p", "03:04 PM"},
+		{"%a, %d %b %Y", "Mon, 02 Jan 2006"},
+		{"%H%%", "15%"},
+	}
+	for _, tc := range testcases {
+		layout, err := goLayout(tc.format)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.format, err)
+			continue
+		}
+		if layout != tc.layout {
+			t.Errorf("%q: got %q, want %q", tc.format, layout, tc.layout)
+		}
+		format, ok := strptime(tc.layout)
+		if !ok || format != tc.format {
+			t.Errorf("%q: got %q, %v, want %q", tc.layout, format, ok, tc.format)
+		}
+	}
+
+	if _, err := goLayout("%Q"); err == nil {
+		t.Error("want error for %Q")
+	}
+	if _, ok := strptime(time.RFC3339); ok {
+		t.Error("want false for RFC 3339")
+	}
+}
@@ -0,0 +1,276 @@
+package tableschema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	headercsv "github.com/shogo82148/go-header-csv"
+)
+
+// A ConstraintError describes a value that violates the type or a constraint of its field.
+// It is reported as the Err of a headercsv.DecodeError.
+type ConstraintError struct {
+	// Constraint is the name of the violated constraint, such as "required" or "maximum".
+	// It is "type" if the value is not in the type and format of the field,
+	// and "primaryKey" if the primary key is duplicated.
+	Constraint string
+	msg        string
+}
+
+func (e *ConstraintError) Error() string {
+	return e.msg
+}
+
+func constraintErrorf(constraint, format string, a ...any) *ConstraintError {
+	return &ConstraintError{
+		Constraint: constraint,
+		msg:        fmt.Sprintf(format, a...),
+	}
+}
+
+// Validate reads all records from dec and checks them against the schema.
+// The header of dec must have the same columns as the fields of the schema, in any order.
+//
+// Values that violate the schema are reported as *headercsv.DecodeError with the position of the field,
+// whose Err is a *ConstraintError.
+// Validate honors the CollectErrors, MaxErrors and ErrorHandler of dec;
+// in the collect-all-errors mode, it returns headercsv.DecodeErrors.
+func (s *Schema) Validate(dec *headercsv.Decoder) error {
+	return s.ValidateContext(context.Background(), dec)
+}
+
+// ValidateContext is like Validate, but it stops reading and returns a *headercsv.CanceledError if ctx is done.
+func (s *Schema) ValidateContext(ctx context.Context, dec *headercsv.Decoder) error {
+	c, err := s.compile()
+	if err != nil {
+		return err
+	}
+	header, err := dec.Header()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("tableschema: the header is not found")
+		}
+		return err
+	}
+	indexes, err := c.indexes(header)
+	if err != nil {
+		return err
+	}
+
+	v := &validator{
+		compiled: c,
+		dec:      dec,
+		header:   header,
+		indexes:  indexes,
+		unique:   make([]map[string]bool, len(c.columns)),
+		values:   make([]any, len(c.columns)),
+		keys:     make(map[string]bool),
+	}
+	for i, col := range c.columns {
+		if col.unique {
+			v.unique[i] = make(map[string]bool)
+		}
+	}
+
+	var record []string
+	for {
+		err := dec.DecodeRecordContext(ctx, &record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var errs headercsv.DecodeErrors
+		if dec.CollectErrors && errors.As(err, &errs) {
+			// the records rejected by the CSV parser.
+			v.errs = append(v.errs, errs...)
+		} else if err != nil {
+			return err
+		} else if err := v.validate(record); err != nil {
+			return err
+		}
+		if dec.MaxErrors > 0 && len(v.errs) >= dec.MaxErrors {
+			v.errs = v.errs[:dec.MaxErrors]
+			break
+		}
+	}
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// indexes returns the indexes of the columns in the header.
+func (c *compiled) indexes(header []string) ([]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		if _, ok := positions[name]; ok {
+			return nil, fmt.Errorf("tableschema: duplicated column %q in the header", name)
+		}
+		positions[name] = i
+	}
+
+	indexes := make([]int, len(c.columns))
+	for i, col := range c.columns {
+		j, ok := positions[col.Name]
+		if !ok {
+			return nil, fmt.Errorf("tableschema: column %q is not found in the header", col.Name)
+		}
+		indexes[i] = j
+		delete(positions, col.Name)
+	}
+	for _, name := range header {
+		if _, ok := positions[name]; ok {
+			return nil, fmt.Errorf("tableschema: column %q is not defined in the schema", name)
+		}
+	}
+	return indexes, nil
+}
+
+type validator struct {
+	*compiled
+	dec     *headercsv.Decoder
+	header  []string
+	indexes []int // the indexes of the columns in the header
+
+	unique []map[string]bool // the keys of the values of the unique columns
+	keys   map[string]bool   // the primary keys
+	values []any             // the values of the current record
+	errs   headercsv.DecodeErrors
+}
+
+// validate checks the record.
+// It returns a non-nil error if validating should stop.
+func (v *validator) validate(record []string) error {
+	for i, col := range v.columns {
+		v.values[i] = nil
+		text := record[v.indexes[i]]
+		if v.missing(text) {
+			if col.required {
+				err := constraintErrorf("required", "the value is required")
+				if skip, err := v.report(record, i, err); skip || err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		value, err := col.cast(text)
+		if err == nil {
+			err = col.check(text, value)
+		} else {
+			err = &ConstraintError{Constraint: "type", msg: err.Error()}
+		}
+		if err == nil && col.unique {
+			k := key(value)
+			if v.unique[i][k] {
+				err = constraintErrorf("unique", "%q is duplicated", text)
+			}
+			v.unique[i][k] = true
+		}
+		if err != nil {
+			if skip, err := v.report(record, i, err); skip || err != nil {
+				return err
+			}
+			continue
+		}
+		v.values[i] = value
+	}
+
+	if len(v.primaryKey) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(v.primaryKey))
+	for _, i := range v.primaryKey {
+		if v.values[i] == nil {
+			// the error of the field has been reported.
+			return nil
+		}
+		keys = append(keys, key(v.values[i]))
+	}
+	k := strings.Join(keys, "\x00")
+	if v.keys[k] {
+		err := constraintErrorf("primaryKey", "the primary key is duplicated")
+		_, err2 := v.report(record, v.primaryKey[0], err)
+		return err2
+	}
+	v.keys[k] = true
+	return nil
+}
+
+// check checks the constraints of the column.
+func (col *column) check(text string, value any) error {
+	if col.minLength >= 0 || col.maxLength >= 0 {
+		n := utf8.RuneCountInString(text)
+		if col.minLength >= 0 && n < col.minLength {
+			return constraintErrorf("minLength", "the length is less than %d", col.minLength)
+		}
+		if col.maxLength >= 0 && n > col.maxLength {
+			return constraintErrorf("maxLength", "the length is greater than %d", col.maxLength)
+		}
+	}
+	if col.minimum != nil && compare(value, col.minimum) < 0 {
+		return constraintErrorf("minimum", "%q is less than the minimum %v", text, col.Constraints.Minimum)
+	}
+	if col.maximum != nil && compare(value, col.maximum) > 0 {
+		return constraintErrorf("maximum", "%q is greater than the maximum %v", text, col.Constraints.Maximum)
+	}
+	if col.pattern != nil && !col.pattern.MatchString(text) {
+		return constraintErrorf("pattern", "%q does not match the pattern %s", text, col.Constraints.Pattern)
+	}
+	if col.enum != nil && !col.enum[key(value)] {
+		return constraintErrorf("enum", "%q is not one of %s", text, col.enumParam)
+	}
+	return nil
+}
+
+func (v *validator) missing(text string) bool {
+	for _, m := range v.missingValues {
+		if text == m {
+			return true
+		}
+	}
+	return false
+}
+
+// report reports the error of the i-th column.
+// If the record has no such field, the error is reported at the start of the record.
+// It returns true if the rest of the record should be skipped,
+// and a non-nil error if validating should stop.
+func (v *validator) report(record []string, i int, err error) (bool, error) {
+	idx := v.indexes[i]
+	startLine, startCol := v.dec.FieldPos(0)
+	line, col := startLine, startCol
+	if idx < v.dec.Fields() {
+		line, col = v.dec.FieldPos(idx)
+	}
+	decodeErr := &headercsv.DecodeError{
+		Record:    v.dec.Records(),
+		StartLine: startLine,
+		Line:      line,
+		Column:    col,
+		Field:     v.header[idx],
+		Value:     record[idx],
+		Err:       err,
+	}
+
+	if v.dec.ErrorHandler != nil {
+		switch v.dec.ErrorHandler(decodeErr, record) {
+		case headercsv.SkipRow:
+			return true, nil
+		case headercsv.UseZero:
+			return false, nil
+		}
+	}
+
+	if !v.dec.CollectErrors {
+		return true, decodeErr
+	}
+	v.errs = append(v.errs, decodeErr)
+	if v.dec.MaxErrors > 0 && len(v.errs) >= v.dec.MaxErrors {
+		return true, v.errs
+	}
+	return false, nil
+}
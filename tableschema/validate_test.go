@@ -0,0 +1,260 @@
+package tableschema
+
+import (
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	headercsv "github.com/shogo82148/go-header-csv"
+)
+
+func mustLoad(t *testing.T, in string) *Schema {
+	t.Helper()
+	s, err := Load(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestValidate(t *testing.T) {
+	testcases := []struct {
+		name       string
+		field      string
+		in         string
+		constraint string
+	}{
+		// types and formats
+		{"integer", `{"name": "a", "type": "integer"}`, "a\n1\n-2\n", ""},
+		{"not integer", `{"name": "a", "type": "integer"}`, "a\n1.5\n", "type"},
+		{"number", `{"name": "a", "type": "number"}`, "a\n1.5\nNaN\n-INF\n", ""},
+		{"not number", `{"name": "a", "type": "number"}`, "a\nabc\n", "type"},
+		{"decimal char", `{"name": "a", "type": "number", "decimalChar": ",", "groupChar": "."}`, "a\n\"1.000,5\"\n", ""},
+		{"bare number", `{"name": "a", "type": "number", "bareNumber": false}`, "a\n$10\n95%\n", ""},
+		{"boolean", `{"name": "a", "type": "boolean"}`, "a\ntrue\nFALSE\n1\n", ""},
+		{"not boolean", `{"name": "a", "type": "boolean"}`, "a\nyes\n", "type"},
+		{"true values", `{"name": "a", "type": "boolean", "trueValues": ["yes"], "falseValues": ["no"]}`, "a\nyes\nno\n", ""},
+		{"date", `{"name": "a", "type": "date"}`, "a\n2024-02-29\n", ""},
+		{"not date", `{"name": "a", "type": "date"}`, "a\n2023-02-29\n", "type"},
+		{"date format", `{"name": "a", "type": "date", "format": "%d/%m/%Y"}`, "a\n29/02/2024\n", ""},
+		{"date any", `{"name": "a", "type": "date", "format": "any"}`, "a\n2024/02/29\n\"Feb 29, 2024\"\n", ""},
+		{"time", `{"name": "a", "type": "time"}`, "a\n23:59:59\n", ""},
+		{"datetime", `{"name": "a", "type": "datetime"}`, "a\n2024-02-29T23:59:59Z\n2024-02-29T23:59:59.5+09:00\n", ""},
+		{"not datetime", `{"name": "a", "type": "datetime"}`, "a\n2024-02-29\n", "type"},
+		{"year", `{"name": "a", "type": "year"}`, "a\n2024\n", ""},
+		{"yearmonth", `{"name": "a", "type": "yearmonth"}`, "a\n2024-02\n", ""},
+		{"duration", `{"name": "a", "type": "duration"}`, "a\nP1Y2M10DT2H30M\nPT0.5S\n", ""},
+		{"not duration", `{"name": "a", "type": "duration"}`, "a\nP1YT\n", "type"},
+		{"object", `{"name": "a", "type": "object"}`, "a\n\"{\"\"b\"\": 1}\"\n", ""},
+		{"not object", `{"name": "a", "type": "object"}`, "a\n[1]\n", "type"},
+		{"array", `{"name": "a", "type": "array"}`, "a\n\"[1,2]\"\n", ""},
+		{"email", `{"name": "a", "format": "email"}`, "a\nalice@example.com\n", ""},
+		{"not email", `{"name": "a", "format": "email"}`, "a\nalice\n", "type"},
+		{"uri", `{"name": "a", "format": "uri"}`, "a\nhttps://example.com/\n", ""},
+		{"not uri", `{"name": "a", "format": "uri"}`, "a\nexample.com\n", "type"},
+		{"uuid", `{"name": "a", "format": "uuid"}`, "a\n123e4567-e89b-12d3-a456-426614174000\n", ""},
+		{"binary", `{"name": "a", "format": "binary"}`, "a\naGVsbG8=\n", ""},
+		{"any", `{"name": "a", "type": "any"}`, "a\nanything\n", ""},
+
+		// constraints
+		{"optional", `{"name": "a", "type": "integer"}`, "a\n\"\"\n", ""},
+		{"required", `{"name": "a", "type": "integer", "constraints": {"required": true}}`, "a\n\"\"\n", "required"},
+		{"unique", `{"name": "a", "constraints": {"unique": true}}`, "a\nx\ny\nx\n", "unique"},
+		{"unique number", `{"name": "a", "type": "number", "constraints": {"unique": true}}`, "a\n1\n1.0\n", "unique"},
+		{"unique null", `{"name": "a", "constraints": {"unique": true}}`, "a\n\"\"\n\"\"\n", ""},
+		{"min length", `{"name": "a", "constraints": {"minLength": 2}}`, "a\nx\n", "minLength"},
+		{"max length", `{"name": "a", "constraints": {"maxLength": 2}}`, "a\nあいう\n", "maxLength"},
+		{"minimum", `{"name": "a", "type": "integer", "constraints": {"minimum": 0}}`, "a\n0\n-1\n", "minimum"},
+		{"maximum", `{"name": "a", "type": "number", "constraints": {"maximum": 1.5}}`, "a\n1.5\n1.6\n", "maximum"},
+		{"date maximum", `{"name": "a", "type": "date", "constraints": {"maximum": "2024-12-31"}}`, "a\n2025-01-01\n", "maximum"},
+		{"pattern", `{"name": "a", "constraints": {"pattern": "[A-Z]{3}"}}`, "a\nABC\nABCD\n", "pattern"},
+		{"enum", `{"name": "a", "constraints": {"enum": ["x", "y"]}}`, "a\nx\nz\n", "enum"},
+		{"integer enum", `{"name": "a", "type": "integer", "constraints": {"enum": [1, 2]}}`, "a\n1\n3\n", "enum"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mustLoad(t, `{"fields": [`+tc.field+`]}`)
+			err := s.Validate(headercsv.NewDecoder(strings.NewReader(tc.in)))
+			if tc.constraint == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var consErr *ConstraintError
+			if !errors.As(err, &consErr) {
+				t.Fatalf("want ConstraintError, got %v", err)
+			}
+			if consErr.Constraint != tc.constraint {
+				t.Errorf("got %q, want %q: %v", consErr.Constraint, tc.constraint, err)
+			}
+		})
+	}
+}
+
+func TestValidate_position(t *testing.T) {
+	s := mustLoad(t, `{"fields": [{"name": "id", "type": "integer"}, {"name": "name"}]}`)
+	in := "name,id\n\"multi\nline\",x\n"
+	err := s.Validate(headercsv.NewDecoder(strings.NewReader(in)))
+
+	var decodeErr *headercsv.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("want DecodeError, got %v", err)
+	}
+	want := `headercsv: decode error on line 3 (starting at line 2), column 7, field: "id": "x" is not an integer`
+	if decodeErr.Error() != want {
+		t.Errorf("got %q, want %q", decodeErr.Error(), want)
+	}
+	if decodeErr.Record != 1 || decodeErr.Value != "x" {
+		t.Errorf("got record %d, value %q, want record 1, value \"x\"", decodeErr.Record, decodeErr.Value)
+	}
+}
+
+func TestValidate_missingField(t *testing.T) {
+	s := mustLoad(t, `{"fields": [{"name": "a"}, {"name": "b", "constraints": {"required": true}}]}`)
+	r := csv.NewReader(strings.NewReader("a,b\n1\n"))
+	r.FieldsPerRecord = -1
+	err := s.Validate(headercsv.NewDecoderCSV(r))
+
+	var decodeErr *headercsv.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("want DecodeError, got %v", err)
+	}
+	// the field is missing; the error is reported at the start of the record.
+	if decodeErr.Field != "b" || decodeErr.Line != 2 || decodeErr.Column != 1 {
+		t.Errorf("got field %q, line %d, column %d, want field \"b\", line 2, column 1", decodeErr.Field, decodeErr.Line, decodeErr.Column)
+	}
+	var constraintErr *ConstraintError
+	if !errors.As(err, &constraintErr) || constraintErr.Constraint != "required" {
+		t.Errorf("want the required constraint error, got %v", err)
+	}
+}
+
+func TestValidate_recordNumber(t *testing.T) {
+	s := mustLoad(t, `{"fields": [{"name": "id", "type": "integer"}]}`)
+	dec := headercsv.NewDecoder(strings.NewReader("id\n1\nx\n"))
+	var first []string
+	if err := dec.DecodeRecord(&first); err != nil {
+		t.Fatal(err)
+	}
+
+	// the records are numbered from the beginning of the input, like the Decoder.
+	err := s.Validate(dec)
+	var decodeErr *headercsv.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("want DecodeError, got %v", err)
+	}
+	if decodeErr.Record != 2 {
+		t.Errorf("got record %d, want 2", decodeErr.Record)
+	}
+}
+
+func TestValidate_collectParseErrors(t *testing.T) {
+	s := mustLoad(t, `{"fields": [{"name": "a", "type": "integer"}, {"name": "b", "type": "integer"}]}`)
+	dec := headercsv.NewDecoder(strings.NewReader("a,b\n1\nx,2\n"))
+	dec.CollectErrors = true
+	err := s.Validate(dec)
+
+	var errs headercsv.DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want DecodeErrors, got %v", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(errs), errs)
+	}
+	if !errors.Is(errs[0], csv.ErrFieldCount) || errs[0].Record != 1 {
+		t.Errorf("got %v in record %d, want csv.ErrFieldCount in record 1", errs[0], errs[0].Record)
+	}
+	if errs[1].Field != "a" || errs[1].Record != 2 {
+		t.Errorf("got field %q in record %d, want field \"a\" in record 2", errs[1].Field, errs[1].Record)
+	}
+}
+
+func TestValidate_collectErrors(t *testing.T) {
+	s := mustLoad(t, `{
+  "fields": [
+    {"name": "id", "type": "integer"},
+    {"name": "group", "type": "integer"},
+    {"name": "name", "constraints": {"required": true}}
+  ],
+  "primaryKey": ["id", "group"],
+  "missingValues": ["", "-"]
+}`)
+	in := "id,group,name\n" +
+		"1,1,alice\n" +
+		"1,2,bob\n" +
+		"1,1,charlie\n" +
+		"-,1,-\n"
+	dec := headercsv.NewDecoder(strings.NewReader(in))
+	dec.CollectErrors = true
+	err := s.Validate(dec)
+
+	var errs headercsv.DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want DecodeErrors, got %v", err)
+	}
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		`headercsv: decode error on line 4, column 1, field "id": the primary key is duplicated`,
+		`headercsv: decode error on line 5, column 1, field "id": the value is required`,
+		`headercsv: decode error on line 5, column 5, field "name": the value is required`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// MaxErrors limits the errors.
+	dec = headercsv.NewDecoder(strings.NewReader(in))
+	dec.CollectErrors = true
+	dec.MaxErrors = 2
+	err = s.Validate(dec)
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("want 2 errors, got %v", err)
+	}
+}
+
+func TestValidate_errorHandler(t *testing.T) {
+	s := mustLoad(t, `{"fields": [{"name": "a", "type": "integer"}, {"name": "b", "type": "integer"}]}`)
+	dec := headercsv.NewDecoder(strings.NewReader("a,b\nx,y\n1,z\n"))
+	var fields []string
+	dec.ErrorHandler = func(err *headercsv.DecodeError, raw []string) headercsv.Action {
+		fields = append(fields, err.Field)
+		return headercsv.SkipRow
+	}
+	if err := s.Validate(dec); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("got %q, want %q", fields, want)
+	}
+}
+
+func TestValidate_header(t *testing.T) {
+	s := mustLoad(t, `{"fields": [{"name": "a"}, {"name": "b"}]}`)
+	testcases := []struct {
+		in   string
+		want string
+	}{
+		{"a\n1\n", `tableschema: column "b" is not found in the header`},
+		{"a,b,c\n1,2,3\n", `tableschema: column "c" is not defined in the schema`},
+		{"a,b,a\n1,2,3\n", `tableschema: duplicated column "a" in the header`},
+		{"", "tableschema: the header is not found"},
+	}
+	for _, tc := range testcases {
+		err := s.Validate(headercsv.NewDecoder(strings.NewReader(tc.in)))
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%q: got %v, want %q", tc.in, err, tc.want)
+		}
+	}
+
+	// the order of the columns doesn't matter.
+	if err := s.Validate(headercsv.NewDecoder(strings.NewReader("b,a\n1,2\n"))); err != nil {
+		t.Error(err)
+	}
+}
@@ -44,3 +44,35 @@ func (o tagOptions) Contains(optionName string) bool {
 	}
 	return false
 }
+
+// Get returns the value of the option name=value in a comma-separated list of options.
+func (o tagOptions) Get(optionName string) (string, bool) {
+	s := string(o)
+	for s != "" {
+		var next string
+		i := strings.Index(s, ",")
+		if i >= 0 {
+			s, next = s[:i], s[i+1:]
+		}
+		if name, value, ok := strings.Cut(s, "="); ok && name == optionName {
+			return value, true
+		}
+		s = next
+	}
+	return "", false
+}
+
+// Cut removes the option name=value from a comma-separated list of options.
+// The value extends to the end of the list, so it may contain commas.
+// Cut returns the options before it, the value, and whether the option is found.
+func (o tagOptions) Cut(optionName string) (tagOptions, string, bool) {
+	s := string(o)
+	prefix := optionName + "="
+	if strings.HasPrefix(s, prefix) {
+		return "", s[len(prefix):], true
+	}
+	if i := strings.Index(s, ","+prefix); i >= 0 {
+		return tagOptions(s[:i]), s[i+1+len(prefix):], true
+	}
+	return o, "", false
+}
@@ -28,3 +28,41 @@ func TestTagParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestTagOptionsGet(t *testing.T) {
+	_, opts := parseTag("field,omitempty,order=2,order=3,width=")
+	for _, tt := range []struct {
+		opt   string
+		value string
+		ok    bool
+	}{
+		{"order", "2", true},
+		{"width", "", true},
+		{"omitempty", "", false},
+		{"align", "", false},
+	} {
+		value, ok := opts.Get(tt.opt)
+		if value != tt.value || ok != tt.ok {
+			t.Errorf("Get(%q) = %q, %v, want %q, %v", tt.opt, value, ok, tt.value, tt.ok)
+		}
+	}
+}
+
+func TestTagOptionsCut(t *testing.T) {
+	for _, tt := range []struct {
+		opts   tagOptions
+		before tagOptions
+		value  string
+		ok     bool
+	}{
+		{"omitempty,pattern=^[A-Z]{1,3}$", "omitempty", "^[A-Z]{1,3}$", true},
+		{"pattern=a,b", "", "a,b", true},
+		{"omitempty,max=3", "omitempty,max=3", "", false},
+		{"xpattern=a", "xpattern=a", "", false},
+	} {
+		before, value, ok := tt.opts.Cut("pattern")
+		if before != tt.before || value != tt.value || ok != tt.ok {
+			t.Errorf("%q.Cut(pattern) = %q, %q, %v, want %q, %q, %v", tt.opts, before, value, ok, tt.before, tt.value, tt.ok)
+		}
+	}
+}
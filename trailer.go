@@ -0,0 +1,143 @@
+package headercsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTrailerCount is returned when the number of records declared in the trailer
+// doesn't match the number of records read.
+var ErrTrailerCount = errors.New("headercsv: record count mismatch")
+
+// Trailer returns the records withheld as the trailer, by TrailerLines and IsTrailer.
+// It is complete after the decoder reaches the end of the input.
+func (dec *Decoder) Trailer() [][]string {
+	if r, ok := dec.r.(*trailerReader); ok {
+		return r.trailer
+	}
+	return nil
+}
+
+// initTrailer installs the trailer reader if the trailer is configured.
+func (dec *Decoder) initTrailer() {
+	if dec.TrailerLines <= 0 && dec.IsTrailer == nil {
+		return
+	}
+	if _, ok := dec.r.(*trailerReader); ok {
+		return
+	}
+	dec.r = &trailerReader{dec: dec, r: dec.r}
+}
+
+// bufferedRecord is a record read ahead to find the trailer.
+type bufferedRecord struct {
+	record    []string
+	err       error
+	positions [][2]int // the line and column of each field
+}
+
+// trailerReader reads records ahead and withholds the trailer.
+type trailerReader struct {
+	dec     *Decoder
+	r       recordReader
+	buf     []bufferedRecord
+	cur     bufferedRecord
+	eof     bool
+	trailer [][]string
+}
+
+func (r *trailerReader) Read() ([]string, error) {
+	for !r.eof && len(r.buf) <= r.dec.TrailerLines {
+		if err := r.readAhead(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(r.buf) <= r.dec.TrailerLines {
+		// the rest of the buffer is the trailer.
+		if len(r.buf) > 0 {
+			trailer := make([][]string, 0, len(r.buf)+len(r.trailer))
+			for _, b := range r.buf {
+				trailer = append(trailer, b.record)
+			}
+			r.trailer = append(trailer, r.trailer...)
+			r.buf = nil
+		}
+		if err := r.verify(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	r.cur = r.buf[0]
+	r.buf = r.buf[1:]
+	return r.cur.record, r.cur.err
+}
+
+// readAhead reads a record into the buffer.
+func (r *trailerReader) readAhead() error {
+	record, err := r.r.Read()
+	if errors.Is(err, io.EOF) {
+		r.eof = true
+		return nil
+	}
+	if err != nil && (record == nil || !errors.Is(err, csv.ErrFieldCount)) {
+		return err
+	}
+
+	// copy the record because csv.Reader may reuse it.
+	record = append([]string(nil), record...)
+	if r.dec.IsTrailer != nil && r.dec.IsTrailer(record) {
+		// the record and the rest of the input are the trailer.
+		r.trailer = append(r.trailer, record)
+		for {
+			record, err := r.r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil && (record == nil || !errors.Is(err, csv.ErrFieldCount)) {
+				return err
+			}
+			r.trailer = append(r.trailer, append([]string(nil), record...))
+		}
+		r.eof = true
+		return nil
+	}
+
+	positions := make([][2]int, len(record))
+	for i := range record {
+		line, column := r.r.FieldPos(i)
+		positions[i] = [2]int{line, column}
+	}
+	r.buf = append(r.buf, bufferedRecord{
+		record:    record,
+		err:       err,
+		positions: positions,
+	})
+	return nil
+}
+
+func (r *trailerReader) FieldPos(field int) (line, column int) {
+	if field < 0 || field >= len(r.cur.positions) {
+		panic("out of range index passed to FieldPos")
+	}
+	pos := r.cur.positions[field]
+	return pos[0], pos[1]
+}
+
+// verify checks the number of records declared in the trailer.
+func (r *trailerReader) verify() error {
+	if r.dec.TrailerCount == nil {
+		return nil
+	}
+	count, err := r.dec.TrailerCount(r.trailer)
+	if err != nil {
+		return err
+	}
+	if count != r.dec.records {
+		return fmt.Errorf("%w: the trailer declares %d records, but %d records are read", ErrTrailerCount, count, r.dec.records)
+	}
+	return nil
+}
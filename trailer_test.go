@@ -0,0 +1,110 @@
+package headercsv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type trailerRecord struct {
+	Name string `csv:"name"`
+	Qty  int    `csv:"qty"`
+	Memo string `csv:"memo"`
+}
+
+var trailerWant = []trailerRecord{
+	{"apple", 1, "a"},
+	{"orange", 2, "b"},
+}
+
+func TestDecoder_TrailerLines(t *testing.T) {
+	input := "name,qty,memo\napple,1,a\norange,2,b\nTOTAL,3,\nEOF,2 rows\n"
+	dec := NewDecoder(strings.NewReader(input))
+	dec.TrailerLines = 2
+	var got []trailerRecord
+	if err := dec.DecodeAll(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, trailerWant) {
+		t.Errorf("got %v, want %v", got, trailerWant)
+	}
+	wantTrailer := [][]string{{"TOTAL", "3", ""}, {"EOF", "2 rows"}}
+	if !reflect.DeepEqual(dec.Trailer(), wantTrailer) {
+		t.Errorf("got trailer %q, want %q", dec.Trailer(), wantTrailer)
+	}
+}
+
+func TestDecoder_IsTrailer(t *testing.T) {
+	input := "name,qty,memo\napple,1,a\norange,2,b\nTOTAL,3,\nEOF,2 rows\n"
+	dec := NewDecoder(strings.NewReader(input))
+	dec.IsTrailer = func(record []string) bool {
+		return record[0] == "TOTAL"
+	}
+	var got []trailerRecord
+	for {
+		var r trailerRecord
+		err := dec.DecodeRecord(&r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	if !reflect.DeepEqual(got, trailerWant) {
+		t.Errorf("got %v, want %v", got, trailerWant)
+	}
+	wantTrailer := [][]string{{"TOTAL", "3", ""}, {"EOF", "2 rows"}}
+	if !reflect.DeepEqual(dec.Trailer(), wantTrailer) {
+		t.Errorf("got trailer %q, want %q", dec.Trailer(), wantTrailer)
+	}
+}
+
+func TestDecoder_TrailerCount(t *testing.T) {
+	count := func(trailer [][]string) (int, error) {
+		n, _, _ := strings.Cut(trailer[0][1], " ")
+		return strconv.Atoi(n)
+	}
+
+	t.Run("match", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader("name,qty,memo\napple,1,a\norange,2,b\nEOF,2 rows\n"))
+		dec.TrailerLines = 1
+		dec.TrailerCount = count
+		var got []trailerRecord
+		if err := dec.DecodeAll(&got); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		dec := NewDecoder(strings.NewReader("name,qty,memo\napple,1,a\nEOF,2 rows\n"))
+		dec.TrailerLines = 1
+		dec.TrailerCount = count
+		var got []trailerRecord
+		err := dec.DecodeAll(&got)
+		if !errors.Is(err, ErrTrailerCount) {
+			t.Errorf("want ErrTrailerCount, got %v", err)
+		}
+		if len(got) != 1 {
+			t.Errorf("got %d records, want 1", len(got))
+		}
+	})
+}
+
+func TestDecoder_TrailerErrorPosition(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("name,qty,memo\napple,x,a\nTOTAL,0,\n"))
+	dec.TrailerLines = 1
+	var got []trailerRecord
+	err := dec.DecodeAll(&got)
+	var decErr *DecodeError
+	if !errors.As(err, &decErr) {
+		t.Fatalf("want *DecodeError, got %v", err)
+	}
+	if decErr.Line != 2 || decErr.Column != 7 || decErr.Field != "qty" {
+		t.Errorf("unexpected error: %+v", decErr)
+	}
+}
@@ -0,0 +1,230 @@
+package headercsv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// NewUTF8Reader returns a reader that converts the input from r into UTF-8.
+// It detects the encoding of the input by the byte order mark: UTF-8, UTF-16LE or UTF-16BE.
+// The byte order mark is removed.
+// If there is no byte order mark, the input is assumed to be UTF-8 and passed through.
+func NewUTF8Reader(r io.Reader) io.Reader {
+	return &bomReader{r: r}
+}
+
+// NewEncoderUTF16 returns a new encoder that writes to w in UTF-16 with the byte order mark.
+// order is the byte order of the output; binary.LittleEndian or binary.BigEndian.
+// The invalid UTF-8 sequences in the fields are written as U+FFFD.
+func NewEncoderUTF16(w io.Writer, order binary.ByteOrder) *Encoder {
+	u := &utf16Writer{w: w, order: order}
+	return &Encoder{w: &utf16CSVWriter{Writer: csv.NewWriter(u), u: u}}
+}
+
+// bomReader detects the encoding by the byte order mark on the first read.
+type bomReader struct {
+	r   io.Reader
+	src io.Reader
+	err error
+}
+
+func (r *bomReader) Read(p []byte) (int, error) {
+	if r.src == nil && r.err == nil {
+		r.detect()
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	return r.src.Read(p)
+}
+
+func (r *bomReader) detect() {
+	var buf [3]byte
+	n, err := io.ReadFull(r.r, buf[:])
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		r.err = err
+		return
+	}
+	head := buf[:n]
+	switch {
+	case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+		r.src = r.r
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		r.src = newUTF16Reader(io.MultiReader(bytes.NewReader(head[2:]), r.r), binary.LittleEndian)
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		r.src = newUTF16Reader(io.MultiReader(bytes.NewReader(head[2:]), r.r), binary.BigEndian)
+	default:
+		r.src = io.MultiReader(bytes.NewReader(head), r.r)
+	}
+}
+
+// utf16Reader converts UTF-16 into UTF-8.
+type utf16Reader struct {
+	r       *bufio.Reader
+	order   binary.ByteOrder
+	pending []byte // UTF-8 bytes that are not read yet
+	err     error  // the sticky error of r
+}
+
+func newUTF16Reader(r io.Reader, order binary.ByteOrder) *utf16Reader {
+	return &utf16Reader{
+		r:     bufio.NewReader(r),
+		order: order,
+	}
+}
+
+func (r *utf16Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.pending) == 0 {
+			if r.err != nil {
+				break
+			}
+			if n > 0 && r.r.Buffered() < 2 {
+				// don't block if some data is ready.
+				break
+			}
+			if err := r.decodeRune(); err != nil {
+				// return the data read so far, and the error on the next call.
+				r.err = err
+				break
+			}
+		}
+		m := copy(p[n:], r.pending)
+		r.pending = r.pending[m:]
+		n += m
+	}
+	if n == 0 && r.err != nil {
+		return 0, r.err
+	}
+	return n, nil
+}
+
+// decodeRune decodes a rune into r.pending.
+func (r *utf16Reader) decodeRune() error {
+	u1, err := r.readUnit()
+	if err != nil {
+		return err
+	}
+	ch := rune(u1)
+	if utf16.IsSurrogate(ch) {
+		ch = utf8.RuneError
+		if u1 < 0xDC00 {
+			// it is a high surrogate, the next unit must be a low surrogate.
+			if b, err := r.r.Peek(2); err == nil {
+				u2 := r.order.Uint16(b)
+				if 0xDC00 <= u2 && u2 < 0xE000 {
+					r.r.Discard(2)
+					ch = utf16.DecodeRune(rune(u1), rune(u2))
+				}
+			}
+		}
+	}
+	r.pending = utf8.AppendRune(r.pending[:0], ch)
+	return nil
+}
+
+func (r *utf16Reader) readUnit() (uint16, error) {
+	var buf [2]byte
+	if _, err := io.ReadFull(r.r, buf[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// an odd byte at the end of the input.
+			return utf8.RuneError, nil
+		}
+		return 0, err
+	}
+	return r.order.Uint16(buf[:]), nil
+}
+
+// utf16Writer converts UTF-8 into UTF-16, and writes the byte order mark before the first write.
+type utf16Writer struct {
+	w       io.Writer
+	order   binary.ByteOrder
+	started bool
+	partial []byte // an incomplete UTF-8 sequence of the previous write
+	buf     []byte
+}
+
+func (w *utf16Writer) Write(p []byte) (int, error) {
+	buf := w.buf[:0]
+	if !w.started {
+		w.started = true
+		buf = w.appendUnit(buf, 0xFEFF)
+	}
+
+	n := len(p)
+	if len(w.partial) > 0 {
+		p = append(w.partial, p...)
+		w.partial = nil
+	}
+	for len(p) > 0 {
+		if !utf8.FullRune(p) {
+			w.partial = append([]byte(nil), p...)
+			break
+		}
+		ch, size := utf8.DecodeRune(p)
+		p = p[size:]
+		if r1, r2 := utf16.EncodeRune(ch); r1 != utf8.RuneError {
+			buf = w.appendUnit(buf, uint16(r1))
+			buf = w.appendUnit(buf, uint16(r2))
+		} else {
+			buf = w.appendUnit(buf, uint16(ch))
+		}
+	}
+	w.buf = buf
+	if _, err := w.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// flush writes U+FFFD for the incomplete UTF-8 sequence at the end of the input.
+func (w *utf16Writer) flush() error {
+	if len(w.partial) == 0 {
+		return nil
+	}
+	w.partial = nil
+	buf := w.appendUnit(w.buf[:0], utf8.RuneError)
+	w.buf = buf
+	_, err := w.w.Write(buf)
+	return err
+}
+
+func (w *utf16Writer) appendUnit(buf []byte, u uint16) []byte {
+	var b [2]byte
+	w.order.PutUint16(b[:], u)
+	return append(buf, b[:]...)
+}
+
+// utf16CSVWriter is a csv.Writer that writes to a utf16Writer.
+type utf16CSVWriter struct {
+	*csv.Writer
+	u   *utf16Writer
+	err error
+}
+
+// Flush writes the buffered data, including the incomplete UTF-8 sequence at the end.
+func (w *utf16CSVWriter) Flush() {
+	w.Writer.Flush()
+	if w.Writer.Error() == nil && w.err == nil {
+		w.err = w.u.flush()
+	}
+}
+
+func (w *utf16CSVWriter) Error() error {
+	if err := w.Writer.Error(); err != nil {
+		return err
+	}
+	return w.err
+}
+
+// newCSVReader returns a csv.Reader that reads UTF-8 converted from r.
+func newCSVReader(r io.Reader) *csv.Reader {
+	return csv.NewReader(NewUTF8Reader(r))
+}
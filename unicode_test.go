@@ -0,0 +1,162 @@
+package headercsv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+func encodeUTF16(s string, order binary.ByteOrder, bom bool) []byte {
+	var buf []byte
+	var b [2]byte
+	if bom {
+		order.PutUint16(b[:], 0xFEFF)
+		buf = append(buf, b[:]...)
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		order.PutUint16(b[:], u)
+		buf = append(buf, b[:]...)
+	}
+	return buf
+}
+
+func TestNewUTF8Reader(t *testing.T) {
+	const text = "name,emoji\nこんにちは,😀\n"
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"utf-8", []byte(text), text},
+		{"utf-8 with bom", append([]byte{0xEF, 0xBB, 0xBF}, text...), text},
+		{"utf-16le", encodeUTF16(text, binary.LittleEndian, true), text},
+		{"utf-16be", encodeUTF16(text, binary.BigEndian, true), text},
+		{"empty", []byte{}, ""},
+		{"short", []byte("a"), "a"},
+		{"only bom", []byte{0xFF, 0xFE}, ""},
+		{"odd byte", []byte{0xFF, 0xFE, 'a', 0, 'b'}, "a\ufffd"},
+		{"lone surrogate", []byte{0xFF, 0xFE, 0x3D, 0xD8, 'a', 0}, "�a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(NewUTF8Reader(iotest.OneByteReader(bytes.NewReader(tt.input))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoderUTF16(t *testing.T) {
+	input := encodeUTF16("name,qty\nりんご,1\n", binary.LittleEndian, true)
+	dec := NewDecoder(bytes.NewReader(input))
+	var got []map[string]string
+	if err := dec.DecodeAll(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0]["name"] != "りんご" || got[0]["qty"] != "1" {
+		t.Errorf("unexpected result: %v", got)
+	}
+}
+
+func TestEncoderUTF16(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoderUTF16(&buf, order)
+			if err := enc.EncodeAll([]map[string]string{{"name": "😀"}}); err != nil {
+				t.Fatal(err)
+			}
+			enc.Flush()
+			if err := enc.Error(); err != nil {
+				t.Fatal(err)
+			}
+			want := encodeUTF16("name\n😀\n", order, true)
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("got %x, want %x", buf.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestUTF16Writer_PartialSequence(t *testing.T) {
+	var buf bytes.Buffer
+	w := &utf16Writer{w: &buf, order: binary.LittleEndian}
+	for _, b := range []byte("é😀") {
+		if _, err := w.Write([]byte{b}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := encodeUTF16("é😀", binary.LittleEndian, true)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("got %x, want %x", buf.Bytes(), want)
+	}
+}
+
+// errAfterReader returns data with err, and io.EOF after that.
+type errAfterReader struct {
+	data []byte
+	err  error
+}
+
+func (r *errAfterReader) Read(p []byte) (int, error) {
+	if r.data == nil {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data)
+	r.data = nil
+	return n, r.err
+}
+
+func TestUTF16Reader_StickyError(t *testing.T) {
+	errRead := errors.New("read error")
+	r := newUTF16Reader(&errAfterReader{data: encodeUTF16("ab", binary.LittleEndian, false), err: errRead}, binary.LittleEndian)
+	got, err := io.ReadAll(r)
+	if !errors.Is(err, errRead) {
+		t.Errorf("want the read error, got %v", err)
+	}
+	if string(got) != "ab" {
+		t.Errorf("got %q, want %q", got, "ab")
+	}
+	if _, err := r.Read(make([]byte, 4)); !errors.Is(err, errRead) {
+		t.Errorf("want the read error again, got %v", err)
+	}
+}
+
+func TestEncoderUTF16_InvalidUTF8(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoderUTF16(&buf, binary.LittleEndian)
+	if err := enc.EncodeAll([][]string{{"name"}, {"a\xed\xa0\x80"}}); err != nil {
+		t.Fatal(err)
+	}
+	enc.Flush()
+	if err := enc.Error(); err != nil {
+		t.Fatal(err)
+	}
+	want := encodeUTF16("name\na\ufffd\ufffd\ufffd\n", binary.LittleEndian, true)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("got %x, want %x", buf.Bytes(), want)
+	}
+}
+
+func TestUTF16Writer_TrailingPartialSequence(t *testing.T) {
+	var buf bytes.Buffer
+	w := &utf16Writer{w: &buf, order: binary.LittleEndian}
+	if _, err := w.Write([]byte("a\xe3\x81")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := encodeUTF16("a\ufffd", binary.LittleEndian, true)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("got %x, want %x", buf.Bytes(), want)
+	}
+}
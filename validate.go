@@ -0,0 +1,226 @@
+package headercsv
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A ValidationError describes a field that violates a validation option of its struct tag.
+// It is reported as the Err of a DecodeError.
+//
+// The validation options are:
+//
+//	min=N      the number must be at least N; for strings, the length in runes
+//	max=N      the number must be at most N; for strings, the length in runes
+//	len=N      the field must be exactly N runes long
+//	enum=a|b   the field must be one of the values separated by "|"
+//	pattern=RE the field must match the regular expression RE
+//
+// The pattern option must be the last option of the tag,
+// because the regular expression extends to the end of the tag and may contain commas.
+// len, enum and pattern check the raw text of the field.
+// Empty fields are not validated if the field is a pointer or has the omitempty option.
+type ValidationError struct {
+	Option string // The tag option, such as "min" or "pattern"
+	Param  string // The parameter of the option
+	msg    string
+}
+
+func (e *ValidationError) Error() string {
+	return e.msg
+}
+
+// validation is the set of validation options of a struct field.
+type validation struct {
+	min, max      string
+	minNum        float64
+	maxNum        float64
+	length        string
+	lengthNum     int
+	enum          []string
+	enumParam     string
+	pattern       *regexp.Regexp
+	numeric       bool // min and max compare the decoded number
+	skipEmptyText bool
+
+	// err is the error of invalid options, reported when a field is decoded.
+	err error
+}
+
+// parseValidation parses the validation options of the struct field of type t.
+// It returns the remaining options and nil if there is no validation option.
+func parseValidation(t reflect.Type, opts tagOptions) (tagOptions, *validation) {
+	var v validation
+	found := false
+	opts, pattern, ok := opts.Cut("pattern")
+	if ok {
+		found = true
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			v.setErr(fmt.Errorf("headercsv: invalid pattern option: %w", err))
+		}
+		v.pattern = re
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		v.numeric = true
+	}
+	parseNum := func(name string, n *float64) string {
+		s, ok := opts.Get(name)
+		if !ok {
+			return ""
+		}
+		found = true
+		if !v.numeric && t.Kind() != reflect.String {
+			v.setErr(fmt.Errorf("headercsv: %s option is not supported for type %s", name, t))
+			return s
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			v.setErr(fmt.Errorf("headercsv: invalid %s option: %q", name, s))
+		}
+		*n = f
+		return s
+	}
+	v.min = parseNum("min", &v.minNum)
+	v.max = parseNum("max", &v.maxNum)
+
+	if s, ok := opts.Get("len"); ok {
+		found = true
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			v.setErr(fmt.Errorf("headercsv: invalid len option: %q", s))
+		}
+		v.length, v.lengthNum = s, n
+	}
+	if s, ok := opts.Get("enum"); ok {
+		found = true
+		v.enumParam = s
+		v.enum = strings.Split(s, "|")
+	}
+	if !found {
+		return opts, nil
+	}
+	v.skipEmptyText = opts.Contains("omitempty")
+	return opts, &v
+}
+
+func (v *validation) setErr(err error) {
+	if v.err == nil {
+		v.err = err
+	}
+}
+
+// validate checks the decoded value rv and its raw text.
+func (v *validation) validate(rv reflect.Value, text string) error {
+	if v.err != nil {
+		return v.err
+	}
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if text == "" && v.skipEmptyText {
+		return nil
+	}
+
+	if v.min != "" || v.max != "" {
+		var n float64
+		var unit string
+		if v.numeric {
+			switch rv.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				n = float64(rv.Int())
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				n = float64(rv.Uint())
+			default:
+				n = rv.Float()
+			}
+		} else {
+			n = float64(utf8.RuneCountInString(text))
+			unit = " length"
+		}
+		if v.min != "" && n < v.minNum {
+			return &ValidationError{
+				Option: "min",
+				Param:  v.min,
+				msg:    fmt.Sprintf("less than the minimum%s %s", unit, v.min),
+			}
+		}
+		if v.max != "" && n > v.maxNum {
+			return &ValidationError{
+				Option: "max",
+				Param:  v.max,
+				msg:    fmt.Sprintf("greater than the maximum%s %s", unit, v.max),
+			}
+		}
+	}
+	if v.length != "" && utf8.RuneCountInString(text) != v.lengthNum {
+		return &ValidationError{
+			Option: "len",
+			Param:  v.length,
+			msg:    fmt.Sprintf("length is not %s", v.length),
+		}
+	}
+	if v.enum != nil && !containsString(v.enum, text) {
+		return &ValidationError{
+			Option: "enum",
+			Param:  v.enumParam,
+			msg:    fmt.Sprintf("%q is not one of %s", text, v.enumParam),
+		}
+	}
+	if v.pattern != nil && !v.pattern.MatchString(text) {
+		return &ValidationError{
+			Option: "pattern",
+			Param:  v.pattern.String(),
+			msg:    fmt.Sprintf("%q does not match the pattern %s", text, v.pattern),
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRecord checks the validation options of the struct fields of v,
+// which has been decoded from record by a RecordUnmarshaler.
+func (dec *Decoder) validateRecord(v reflect.Value, record []string) error {
+	rt, ok := recordType(v.Type()).(*structRecordType)
+	if !ok || !rt.validated {
+		return nil
+	}
+	var errs DecodeErrors
+	for i, k := range dec.header {
+		if i >= len(record) {
+			break
+		}
+		fv, f := rt.Field(v, i, k)
+		if f == nil || f.validation == nil {
+			continue
+		}
+		if err := f.validation.validate(fv, record[i]); err != nil {
+			if err := dec.fieldError(&errs, record, i, k, fv, err); err != nil {
+				return err
+			}
+		}
+	}
+	return errs.err()
+}
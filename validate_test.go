@@ -0,0 +1,230 @@
+package headercsv
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validatedRecord struct {
+	Name   string  `csv:"name,min=1,max=5"`
+	Age    int     `csv:"age,min=0,max=150"`
+	Score  *uint   `csv:"score,max=100"`
+	Status string  `csv:"status,omitempty,enum=active|inactive"`
+	Code   string  `csv:"code,len=3,pattern=^[A-Z]{1,3}$"`
+	Ratio  float64 `csv:"ratio,min=0.5"`
+}
+
+func TestDecode_validation(t *testing.T) {
+	header := "name,age,score,status,code,ratio\n"
+	testcases := []struct {
+		name   string
+		in     string
+		field  string
+		column int
+		option string
+	}{
+		{
+			name:  "valid",
+			in:    "alice,20,100,active,ABC,0.5\n",
+			field: "",
+		},
+		{
+			name:  "empty pointer and omitempty",
+			in:    "bob,0,,,XYZ,1\n",
+			field: "",
+		},
+		{
+			name:   "string too short",
+			in:     ",20,1,active,ABC,1\n",
+			field:  "name",
+			column: 1,
+			option: "min",
+		},
+		{
+			name:   "string too long",
+			in:     "alexander,20,1,active,ABC,1\n",
+			field:  "name",
+			column: 1,
+			option: "max",
+		},
+		{
+			name:   "number too small",
+			in:     "alice,-1,1,active,ABC,1\n",
+			field:  "age",
+			column: 7,
+			option: "min",
+		},
+		{
+			name:   "number too large",
+			in:     "alice,151,1,active,ABC,1\n",
+			field:  "age",
+			column: 7,
+			option: "max",
+		},
+		{
+			name:   "pointer too large",
+			in:     "alice,20,101,active,ABC,1\n",
+			field:  "score",
+			column: 10,
+			option: "max",
+		},
+		{
+			name:   "enum",
+			in:     "alice,20,1,deleted,ABC,1\n",
+			field:  "status",
+			column: 12,
+			option: "enum",
+		},
+		{
+			name:   "len",
+			in:     "alice,20,1,active,AB,1\n",
+			field:  "code",
+			column: 19,
+			option: "len",
+		},
+		{
+			name:   "pattern",
+			in:     "alice,20,1,active,abc,1\n",
+			field:  "code",
+			column: 19,
+			option: "pattern",
+		},
+		{
+			name:   "float",
+			in:     "alice,20,1,active,ABC,0.25\n",
+			field:  "ratio",
+			column: 23,
+			option: "min",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(header + tc.in))
+			var v []validatedRecord
+			err := dec.DecodeAll(&v)
+			if tc.field == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("want DecodeError, got %v", err)
+			}
+			if decodeErr.Field != tc.field {
+				t.Errorf("field: got %q, want %q", decodeErr.Field, tc.field)
+			}
+			if decodeErr.Line != 2 {
+				t.Errorf("line: got %d, want 2", decodeErr.Line)
+			}
+			if decodeErr.Column != tc.column {
+				t.Errorf("column: got %d, want %d", decodeErr.Column, tc.column)
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("want ValidationError, got %v", err)
+			}
+			if validationErr.Option != tc.option {
+				t.Errorf("option: got %q, want %q", validationErr.Option, tc.option)
+			}
+		})
+	}
+}
+
+func TestDecode_validationCollectErrors(t *testing.T) {
+	in := "name,age,score,status,code,ratio\n" +
+		"alice,200,1,active,ABC,1\n" +
+		"bob,20,1,unknown,ABC,1\n"
+	dec := NewDecoder(strings.NewReader(in))
+	dec.CollectErrors = true
+	var v []validatedRecord
+	err := dec.DecodeAll(&v)
+
+	var errs DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want DecodeErrors, got %v", err)
+	}
+	want := []string{
+		`headercsv: decode error on line 2, column 7, field "age": greater than the maximum 150`,
+		`headercsv: decode error on line 3, column 10, field "status": "unknown" is not one of active|inactive`,
+	}
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDecode_invalidValidationOption(t *testing.T) {
+	testcases := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{
+			name: "pattern",
+			v: &[]struct {
+				A string `csv:"a,pattern=["`
+			}{},
+			want: "headercsv: invalid pattern option",
+		},
+		{
+			name: "min",
+			v: &[]struct {
+				A int `csv:"a,min=zero"`
+			}{},
+			want: `headercsv: invalid min option: "zero"`,
+		},
+		{
+			name: "unsupported type",
+			v: &[]struct {
+				A bool `csv:"a,max=1"`
+			}{},
+			want: "headercsv: max option is not supported for type bool",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader("a\n1\n"))
+			err := dec.DecodeAll(tc.v)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+type validatedUnmarshalerRecord struct {
+	Code string `csv:"code,pattern=^[a-z]+$"`
+}
+
+func (r *validatedUnmarshalerRecord) UnmarshalCSVRecord(header, record []string) error {
+	for i, name := range header {
+		if name == "code" && i < len(record) {
+			r.Code = record[i]
+		}
+	}
+	return nil
+}
+
+func TestDecode_validationRecordUnmarshaler(t *testing.T) {
+	dec := NewDecoder(bytes.NewBufferString("id,code\n1,abc\n2,ABC\n"))
+	var v []validatedUnmarshalerRecord
+	err := dec.DecodeAll(&v)
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("want DecodeError, got %v", err)
+	}
+	if decodeErr.Line != 3 || decodeErr.Column != 3 || decodeErr.Field != "code" {
+		t.Errorf("got line %d, column %d, field %q, want line 3, column 3, field \"code\"", decodeErr.Line, decodeErr.Column, decodeErr.Field)
+	}
+}